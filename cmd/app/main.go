@@ -1,14 +1,19 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"time"
 
 	"github.com/ChernykhITMO/compiler/internal/backend"
 	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/loader"
 	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
 	"github.com/ChernykhITMO/compiler/internal/frontend/semantics"
+	"github.com/ChernykhITMO/compiler/internal/optimizer"
 )
 
 type Scenario string
@@ -36,32 +41,71 @@ func getScenarioSource(s Scenario) string {
 const currentScenario = ScenarioSort
 
 func main() {
-	src := getScenarioSource(currentScenario)
+	noInline := flag.Bool("no-inline", false, "disable inlining of small functions")
+	inlineReport := flag.Bool("inline-report", false, "print which functions were inlined")
+	checked := flag.Bool("checked", false, "report int64 overflow as a runtime error")
+	file := flag.String("file", "", "run test() from an .easy file instead of the built-in scenario")
+	flag.Parse()
+
+	var units []*loader.Unit
+	if *file != "" {
+		var err error
+		units, err = loader.NewLoader().Load(*file)
+		if err != nil {
+			log.Fatalf("load error: %v", err)
+		}
+	} else {
+		lexer := lexer.NewLexer(getScenarioSource(currentScenario))
+		p := parser.NewParser(lexer.Tokenize())
+		units = []*loader.Unit{{Path: string(currentScenario), Program: p.ParseProgram()}}
+	}
+
+	linked := make([]backend.Unit, 0, len(units))
+	for _, u := range units {
+		prog := u.Program
+
+		checker := semantics.NewChecker()
+		if semErrs := checker.Check(prog); len(semErrs) > 0 {
+			for _, e := range semErrs {
+				fmt.Printf("check: %s: [%s] %s\n", u.Path, e.Type, e.Message)
+			}
+			log.Fatal("semantic check failed")
+		}
+
+		validator := semantics.NewASTValidator()
+		errs := validator.Validate(prog)
+		if len(errs) > 0 {
+			for _, e := range errs {
+				fmt.Printf("validate: %s: [%s] %s\n", u.Path, e.Type, e.Message)
+			}
+			log.Fatal("validation failed")
+		}
 
-	lexer := lexer.NewLexer(src)
-	tokens := lexer.Tokenize()
+		opt := optimizer.NewOptimizer()
+		opt.SetCheckedArithmetic(*checked)
+		for _, w := range opt.Optimize(prog) {
+			fmt.Printf("optimize: %s: [%s] %s\n", u.Path, w.Type, w.Message)
+		}
 
-	p := parser.NewParser(tokens)
-	prog := p.ParseProgram()
+		comp := backend.NewCompiler(!*noInline)
+		mod, err := comp.CompileProgram(prog)
+		if err != nil {
+			log.Fatalf("compile error: %s: %v", u.Path, err)
+		}
 
-	validator := semantics.NewASTValidator()
-	checker := semantics.NewChecker()
-	checker.Check(prog)
-	errs := validator.Validate(prog)
-	if len(errs) > 0 {
-		for _, e := range errs {
-			fmt.Printf("validate: [%s] %s\n", e.Type, e.Message)
+		if *inlineReport {
+			printInlineReports(os.Stdout, comp.InlineReports())
 		}
-		log.Fatal("validation failed")
+		linked = append(linked, backend.Unit{Name: u.Name, Module: mod})
 	}
 
-	comp := backend.NewCompiler()
-	mod, err := comp.CompileProgram(prog)
+	mod, err := backend.Link(linked)
 	if err != nil {
-		log.Fatalf("compile error: %v", err)
+		log.Fatalf("link error: %v", err)
 	}
 
 	vm := backend.NewVM(mod, true)
+	vm.SetCheckedArithmetic(*checked)
 
 	startCall := time.Now()
 	res, err := vm.Call("test", nil)
@@ -72,8 +116,9 @@ func main() {
 		log.Fatalf("vm error: %v", err)
 	}
 
-	switch currentScenario {
-	case ScenarioFactorial:
+	switch {
+	case *file != "":
+	case currentScenario == ScenarioFactorial:
 		const want = 2432902008176640000
 		if res.I != want {
 			log.Fatalf("wrong result: want %d got %d", want, res.I)
@@ -85,6 +130,16 @@ func main() {
 	fmt.Println("OK, test() returned", res.I)
 }
 
+// printInlineReports печатает для -inline-report, какие функции куда
+// подставлены.
+func printInlineReports(w io.Writer, reports []backend.InlineReport) {
+	for _, r := range reports {
+		for _, callee := range r.Callees() {
+			fmt.Fprintf(w, "inline: %s <- %s (%d call sites)\n", r.Function, callee, r.Inlined[callee])
+		}
+	}
+}
+
 // факториал
 const srcFactorial = `
 function main() void {
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+)
+
+func TestPrintInlineReports(t *testing.T) {
+	var out bytes.Buffer
+	printInlineReports(&out, []backend.InlineReport{
+		{Function: "test", Inlined: map[string]int{"sq": 2, "add": 1}},
+		{Function: "sum", Inlined: map[string]int{"add": 3}},
+	})
+
+	want := "inline: test <- add (1 call sites)\n" +
+		"inline: test <- sq (2 call sites)\n" +
+		"inline: sum <- add (3 call sites)\n"
+	if out.String() != want {
+		t.Fatalf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,69 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArrayLiteralAndLen(t *testing.T) {
+	res, err := run(t, `
+function test() int {
+    int[] a = [3, 1 + 1, 7]
+    a[1] = a[1] * 10
+    return len(a) * 100 + a[0] + a[1] + a[2] + len([5])
+}
+`)
+	if err != nil || res.I != 331 {
+		t.Fatalf("test() = %d, %v; want 331", res.I, err)
+	}
+}
+
+func TestLenOfNullArray(t *testing.T) {
+	_, err := run(t, `
+function test() int {
+    int[] a
+    return len(a)
+}
+`)
+	if err == nil || !strings.Contains(err.Error(), "[NullReference] len: value is null in function test") {
+		t.Fatalf("err = %v, want len error", err)
+	}
+}
+
+func TestNewMultiDimensionalArray(t *testing.T) {
+	res, err := run(t, `
+function test() int {
+    int[][][] m = new int[2][3][4]
+    m[0][1][2] = 5
+    m[1][1][2] = m[1][1][2] + 1
+    return len(m) * 1000 + len(m[1]) * 100 + len(m[1][2]) * 10 + m[0][1][2] + m[1][1][2] + m[0][0][0]
+}
+`)
+	if err != nil || res.I != 2346 {
+		t.Fatalf("test() = %d, %v; want 2346", res.I, err)
+	}
+}
+
+func TestNewJaggedArray(t *testing.T) {
+	res, err := run(t, `
+function test() int {
+    int[][] m = new int[3][]
+    m[0] = new int[4]
+    m[2] = [1, 2]
+    return len(m[0]) * 10 + len(m[2])
+}
+`)
+	if err != nil || res.I != 42 {
+		t.Fatalf("test() = %d, %v; want 42", res.I, err)
+	}
+
+	_, err = run(t, `
+function test() int {
+    int[][] m = new int[3][]
+    return m[1][0]
+}
+`)
+	if err == nil {
+		t.Fatalf("reading from a null row succeeded")
+	}
+}
@@ -0,0 +1,38 @@
+package backend_test
+
+import "testing"
+
+func TestCompoundAssignment(t *testing.T) {
+	res, err := run(t, `
+struct Box { int v }
+
+int calls = 0
+
+function at() int {
+    calls++
+    return 1
+}
+
+function test() int {
+    int[] a = [10, 20, 30]
+    a[at()] += 5
+    a[at()] *= 2
+    a[0] -= 3
+    a[2] /= 4
+    a[2] %= 5
+
+    Box b = new Box
+    b.v += 7
+    b.v--
+    int s = 0
+    for (int i = 0; i < 3; i++) {
+        s += a[i]
+    }
+    return s * 100 + b.v * 10 + calls
+}
+`)
+	// a = [7, 50, 2], b.v = 6, at() вызвана дважды
+	if err != nil || res.I != 5962 {
+		t.Fatalf("test() = %d, %v; want 5962", res.I, err)
+	}
+}
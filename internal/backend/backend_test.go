@@ -0,0 +1,63 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+	"github.com/ChernykhITMO/compiler/internal/backend/jit"
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+	"github.com/ChernykhITMO/compiler/internal/frontend/semantics"
+	"github.com/ChernykhITMO/compiler/internal/optimizer"
+)
+
+// compile проводит src через все проходы перед VM, как cmd/app.
+// Функция main, которую требует валидатор, добавляется сама.
+func compile(t *testing.T, src string, inline bool) (*bytecode.Module, *backend.Compiler) {
+	t.Helper()
+	prog := parser.NewParser(lexer.NewLexer(src + "\nfunction main() void {\n}\n").Tokenize()).ParseProgram()
+	if errs := semantics.NewChecker().Check(prog); len(errs) > 0 {
+		t.Fatalf("check: [%s] %s", errs[0].Type, errs[0].Message)
+	}
+	if errs := semantics.NewASTValidator().Validate(prog); len(errs) > 0 {
+		t.Fatalf("validate: [%s] %s", errs[0].Type, errs[0].Message)
+	}
+	optimizer.NewOptimizer().Optimize(prog)
+	comp := backend.NewCompiler(inline)
+	mod, err := comp.CompileProgram(prog)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	return mod, comp
+}
+
+// run компилирует src с подстановкой функций и вызывает test().
+func run(t *testing.T, src string) (bytecode.Value, error) {
+	t.Helper()
+	mod, _ := compile(t, src, true)
+	return call(mod)
+}
+
+func call(mod *bytecode.Module) (bytecode.Value, error) {
+	return backend.NewVM(mod, true).Call("test", nil)
+}
+
+// opCounts считает инструкции функции name в скомпилированном коде.
+func opCounts(t *testing.T, mod *bytecode.Module, name string) map[bytecode.OpCode]int {
+	t.Helper()
+	fn, ok := mod.Functions[name]
+	if !ok {
+		t.Fatalf("no function %s", name)
+	}
+	counts := make(map[bytecode.OpCode]int)
+	for ip := 0; ip < len(fn.Chunk.Code); {
+		in, ok := jit.Decode(fn.Chunk.Code, ip)
+		if !ok {
+			t.Fatalf("%s: cannot decode at %d", name, ip)
+		}
+		counts[in.OpCode]++
+		ip += in.Size
+	}
+	return counts
+}
@@ -0,0 +1,39 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBitwiseOperators(t *testing.T) {
+	res, err := run(t, `
+function test() int {
+    int x = 12
+    int y = 10
+    int r = (x & y) + (x | y) * 100 + (x xor y) * 10000
+    r = r + (~x + 13) * 1000000
+    x <<= 2
+    x |= 1
+    int n = -16
+    n >>= 2
+    return r + x * 100000000 + n * 10000000000
+}
+`)
+	// 8 + 14*100 + 6*10000 + 0*1000000 + 49*100000000 + (-4)*10000000000
+	want := int64(8 + 1400 + 60000 + 4900000000 - 40000000000)
+	if err != nil || res.I != want {
+		t.Fatalf("test() = %d, %v; want %d", res.I, err, want)
+	}
+}
+
+func TestNegativeShift(t *testing.T) {
+	_, err := run(t, `
+function test() int {
+    int s = -1
+    return 1 << s
+}
+`)
+	if err == nil || !strings.Contains(err.Error(), "shift") {
+		t.Fatalf("err = %v, want negative shift error", err)
+	}
+}
@@ -0,0 +1,106 @@
+package backend_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+	"github.com/ChernykhITMO/compiler/internal/backend/jit"
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+func TestUncheckedArrayAccessEmitted(t *testing.T) {
+	mod, _ := compile(t, `
+function test() int {
+    int n = 10
+    int[] a = new int[n]
+    int i = 0
+    while (i < n) {
+        a[i] = i
+        i = i + 1
+    }
+    int s = 0
+    i = 0
+    while (i < n) {
+        s = s + a[i]
+        i = i + 1
+    }
+    return s + a[n - 1]
+}
+`, false)
+	ops := opCounts(t, mod, "test")
+	if ops[bytecode.OpArraySetUnchecked] != 1 || ops[bytecode.OpArrayGetUnchecked] != 1 {
+		t.Fatalf("unchecked set/get = %d/%d, want 1/1",
+			ops[bytecode.OpArraySetUnchecked], ops[bytecode.OpArrayGetUnchecked])
+	}
+	if ops[bytecode.OpArrayGet] != 1 {
+		t.Fatalf("OpArrayGet = %d, want 1 for a[n - 1] outside the loop", ops[bytecode.OpArrayGet])
+	}
+	res, err := call(mod)
+	if err != nil || res.I != 54 {
+		t.Fatalf("test() = %d, %v; want 54", res.I, err)
+	}
+}
+
+// uncheck заменяет в fn проверяемые обращения к массиву непроверяемыми,
+// как если бы оптимизатор ошибочно доказал границы.
+func uncheck(t *testing.T, mod *bytecode.Module, fn string) {
+	t.Helper()
+	code := mod.Functions[fn].Chunk.Code
+	patched := false
+	for ip := 0; ip < len(code); {
+		in, ok := jit.Decode(code, ip)
+		if !ok {
+			t.Fatalf("%s: cannot decode at %d", fn, ip)
+		}
+		switch in.OpCode {
+		case bytecode.OpArrayGet:
+			code[ip] = byte(bytecode.OpArrayGetUnchecked)
+			patched = true
+		case bytecode.OpArraySet:
+			code[ip] = byte(bytecode.OpArraySetUnchecked)
+			patched = true
+		}
+		ip += in.Size
+	}
+	if !patched {
+		t.Fatalf("no array access in %s", fn)
+	}
+}
+
+// Неверно доказанная граница не должна ронять процесс: VM сообщает
+// ошибку с позицией обращения.
+func TestUncheckedIndexFault(t *testing.T) {
+	src := `
+function test() int {
+    int[] a = new int[1]
+    int i = 3
+    return a[i]
+}
+`
+	mod, _ := compile(t, src, false)
+	uncheck(t, mod, "test")
+	_, err := call(mod)
+	want := fmt.Sprintf("array get: index out of range [3] with length 1 in function test at pos %d", strings.Index(src, "[i]"))
+	if err == nil || err.Error() != want {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestUncheckedNullArray(t *testing.T) {
+	mod, _ := compile(t, `
+function test() int {
+    int[] a
+    a[0] = 1
+    return 0
+}
+`, false)
+	uncheck(t, mod, "test")
+	_, err := call(mod)
+	var ref *backend.NullReference
+	if !errors.As(err, &ref) {
+		t.Fatalf("err = %v, want NullReference", err)
+	}
+}
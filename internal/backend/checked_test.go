@@ -0,0 +1,64 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+)
+
+const overflowSrc = `
+function test() int {
+    int x = 9223372036854775806
+    x++
+    x = x + 1
+    return x
+}
+`
+
+func TestWrappingArithmetic(t *testing.T) {
+	res, err := run(t, overflowSrc)
+	if err != nil || res.I != -9223372036854775808 {
+		t.Fatalf("test() = %d, %v; want wrapped MinInt64", res.I, err)
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"add", overflowSrc, "integer overflow: 9223372036854775807 + 1"},
+		{"increment", `
+function test() int {
+    int x = 9223372036854775807
+    x++
+    return x
+}
+`, "integer overflow: 9223372036854775807 + 1"},
+		{"power", `
+function test() int {
+    int b = 3
+    return b ^ 40
+}
+`, "integer overflow: 3 ^ 40"},
+		{"negate", `
+function test() int {
+    int x = -9223372036854775807 - 1
+    return -x
+}
+`, "integer overflow: -(-9223372036854775808)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, _ := compile(t, tt.src, false)
+			vm := backend.NewVM(mod, true)
+			vm.SetCheckedArithmetic(true)
+			_, err := vm.Call("test", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
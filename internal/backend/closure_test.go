@@ -0,0 +1,69 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClosures(t *testing.T) {
+	res, err := run(t, `
+function apply(function(int) int f, int x) int {
+    return f(x)
+}
+
+function makeCounter() function() int {
+    int n = 0
+    return function() int {
+        n = n + 1
+        return n
+    }
+}
+
+function sq(int x) int {
+    return x * x
+}
+
+function test() int {
+    function() int a = makeCounter()
+    function() int b = makeCounter()
+    a()
+    a()
+    int fromA = a()
+    int fromB = b()
+
+    int shared = 1
+    function() void add = function() {
+        shared = shared * 10
+    }
+    add()
+    add()
+
+    function() int first = null
+    function() int last = null
+    for (int i = 0; i < 3; i++) {
+        int k = i
+        last = function() int { return k }
+        if (i == 0) first = last
+    }
+
+    function(int) int g = sq
+    return fromA * 100000 + fromB * 10000 + shared + apply(g, 3) * 1000000 + first() + last() * 10
+}
+`)
+	// a() -> 3, b() -> 1, shared = 100, sq(3) = 9, first -> 0, last -> 2
+	if err != nil || res.I != 9310120 {
+		t.Fatalf("test() = %d, %v; want 9310120", res.I, err)
+	}
+}
+
+func TestNullFunctionCall(t *testing.T) {
+	_, err := run(t, `
+function test() int {
+    function(int) int f
+    return f(1)
+}
+`)
+	if err == nil || !strings.Contains(err.Error(), "indirect call: function is null") {
+		t.Fatalf("err = %v, want null function call", err)
+	}
+}
@@ -0,0 +1,85 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLists(t *testing.T) {
+	res, err := run(t, `
+function test() int {
+    list<int> xs = new list<int>
+    for (int i = 1; i <= 5; i++) {
+        xs.push(i * i)
+    }
+    int last = xs.pop()
+    xs.set(0, xs.get(0) + 100)
+
+    list<list<int>> rows = new list<list<int>>
+    rows.push(xs)
+    rows.get(0).push(7)
+    return len(xs) * 10000 + last * 100 + xs.get(0) + xs.get(4)
+}
+`)
+	// xs = [101, 4, 9, 16, 7], last = 25
+	if err != nil || res.I != 52608 {
+		t.Fatalf("test() = %d, %v; want 52608", res.I, err)
+	}
+}
+
+func TestMaps(t *testing.T) {
+	res, err := run(t, `
+function test() string {
+    map<string, int> ages = new map<string, int>
+    ages.set("cid", 3)
+    ages.set("ann", 30)
+    ages.set("bob", 25)
+    ages.set("ann", 31)
+    ages.delete("bob")
+
+    string s = ""
+    list<string> names = ages.keys()
+    for (int i = 0; i < len(names); i++) {
+        s += names.get(i) + "=" + str(ages.get(names.get(i))) + " "
+    }
+
+    map<int, bool> seen = new map<int, bool>
+    seen.set(2, true)
+    return s + str(len(ages)) + str(ages.has("bob")) + str(seen.has(2))
+}
+`)
+	want := "ann=31 cid=3 2falsetrue"
+	if err != nil || res.S != want {
+		t.Fatalf("test() = %q, %v; want %q", res.S, err, want)
+	}
+}
+
+func TestCollectionRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"pop empty", `
+    list<int> xs = new list<int>
+    return xs.pop()`, "pop: list is empty"},
+		{"index out of range", `
+    list<int> xs = new list<int>
+    xs.push(1)
+    return xs.get(1)`, "get: index 1 out of range [0,1)"},
+		{"missing key", `
+    map<string, int> m = new map<string, int>
+    return m.get("x")`, `get: key x not found`},
+		{"null list", `
+    list<int> xs
+    return xs.get(0)`, "[NullReference] get: list is null in function test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, "function test() int {"+tt.body+"\n}\n")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package backend
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 
+	"github.com/ChernykhITMO/compiler/internal/backend/native"
 	"github.com/ChernykhITMO/compiler/internal/bytecode"
 	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
 	"github.com/ChernykhITMO/compiler/internal/frontend/token"
@@ -14,22 +16,45 @@ type localVar struct {
 	name string
 	slot int // индекс в locals во фрейме vm
 	typ  bytecode.TypeKind
+	cell bool // в слоте лежит ячейка (ObjUpvalue): переменную захватывает вложенная функция
+}
+
+type globalVar struct {
+	slot int // индекс в Module.Globals
+	decl *ast.VarDeclStmt
 }
 
 type Compiler struct {
-	mod    *bytecode.Module
-	fn     *bytecode.FunctionInfo
-	locals []localVar
+	mod     *bytecode.Module
+	fn      *bytecode.FunctionInfo
+	locals  []localVar
+	globals map[string]globalVar
+	externs map[string]string // функция другого модуля -> имя при линковке
 
 	breakStack    [][]int
 	continueStack [][]int
+	tries         int // глубина try: внутри нет хвостовых вызовов, обработчик живет во фрейме
+	depth         int // значений на стеке операндов под вычисляемым выражением
+
+	isActivatedInline bool
+	inlinable         map[string]*ast.FunctionDecl
+	inlines           []*inlineFrame
+	inlined           map[string]map[string]int // функция -> что в нее подставлено
+	order             []string
 }
 
-func NewCompiler() *Compiler {
+func NewCompiler(isActivatedInline bool) *Compiler {
 	functions := make(map[string]*bytecode.FunctionInfo)
-	module := &bytecode.Module{Functions: functions}
-
-	return &Compiler{mod: module}
+	structs := make(map[string]*bytecode.StructInfo)
+	module := &bytecode.Module{Functions: functions, Structs: structs}
+
+	return &Compiler{
+		mod:               module,
+		globals:           make(map[string]globalVar),
+		externs:           make(map[string]string),
+		isActivatedInline: isActivatedInline,
+		inlined:           make(map[string]map[string]int),
+	}
 }
 
 func (c *Compiler) chunk() *bytecode.Chunk {
@@ -42,7 +67,7 @@ func (c *Compiler) Module() *bytecode.Module {
 
 func mapTypeName(t types.Type) bytecode.TypeKind {
 	switch t.Kind {
-	case types.TypeInt:
+	case types.TypeInt, types.TypeEnum:
 		return bytecode.TypeInt
 	case types.TypeFloat:
 		return bytecode.TypeFloat
@@ -56,6 +81,16 @@ func mapTypeName(t types.Type) bytecode.TypeKind {
 		return bytecode.TypeVoid
 	case types.TypeArray:
 		return bytecode.TypeArray
+	case types.TypeStruct:
+		return bytecode.TypeStruct
+	case types.TypeFunction:
+		return bytecode.TypeFunction
+	case types.TypeParam:
+		return bytecode.TypeAny
+	case types.TypeList:
+		return bytecode.TypeList
+	case types.TypeMap:
+		return bytecode.TypeMap
 	default:
 		return bytecode.TypeInvalid
 	}
@@ -72,7 +107,12 @@ func (c *Compiler) addLocal(name string, typ bytecode.TypeKind) int {
 }
 
 func (c *Compiler) resolveLocal(name string) (int, bool) {
-	for i := len(c.locals) - 1; i >= 0; i-- {
+	// внутри подставленного тела видны только его собственные локалы
+	base := 0
+	if n := len(c.inlines); n > 0 {
+		base = c.inlines[n-1].base
+	}
+	for i := len(c.locals) - 1; i >= base; i-- {
 		if c.locals[i].name == name {
 			return c.locals[i].slot, true
 		}
@@ -82,10 +122,40 @@ func (c *Compiler) resolveLocal(name string) (int, bool) {
 
 func (c *Compiler) CompileProgram(p *ast.Program) (*bytecode.Module, error) {
 
+	for _, s := range p.Structs {
+		if _, exists := c.mod.Structs[s.Name]; exists {
+			return nil, fmt.Errorf("duplicate struct: %s", s.Name)
+		}
+
+		info := &bytecode.StructInfo{
+			Name:       s.Name,
+			Fields:     make([]string, len(s.Fields)),
+			FieldTypes: make([]bytecode.TypeKind, len(s.Fields)),
+		}
+		for i, f := range s.Fields {
+			info.Fields[i] = f.Name
+			info.FieldTypes[i] = mapTypeName(f.Type)
+		}
+
+		c.mod.Structs[s.Name] = info
+	}
+
+	for _, g := range p.Globals {
+		if _, exists := c.globals[g.Name]; exists {
+			return nil, fmt.Errorf("duplicate global: %s", g.Name)
+		}
+		c.globals[g.Name] = globalVar{slot: len(c.mod.Globals), decl: g}
+		c.mod.Globals = append(c.mod.Globals, g.Name)
+	}
+
 	for _, fn := range p.Functions {
 		if _, exists := c.mod.Functions[fn.Name]; exists {
 			return nil, fmt.Errorf("duplicate function: %s", fn.Name)
 		}
+		if fn.Extern != "" {
+			c.externs[fn.Name] = fn.Extern
+			continue
+		}
 
 		bfn := &bytecode.FunctionInfo{
 			Name:       fn.Name,
@@ -99,16 +169,77 @@ func (c *Compiler) CompileProgram(p *ast.Program) (*bytecode.Module, error) {
 		}
 
 		c.mod.Functions[bfn.Name] = bfn
+		c.order = append(c.order, fn.Name)
+	}
+
+	if c.isActivatedInline {
+		c.inlinable = findInlinable(p)
 	}
 
 	for _, fn := range p.Functions {
+		if fn.Extern != "" {
+			continue
+		}
 		if err := c.compileFunction(fn); err != nil {
 			return nil, err
 		}
 	}
+
+	if len(p.Globals) > 0 {
+		if err := c.compileInit(p.Globals); err != nil {
+			return nil, err
+		}
+	}
 	return c.mod, nil
 }
 
+// compileInit собирает bytecode.InitFunction: инициализаторы глобальных
+// переменных по порядку объявления.
+func (c *Compiler) compileInit(globals []*ast.VarDeclStmt) error {
+	body := &ast.BlockStmt{}
+	for _, g := range globals {
+		init := g.Init
+		if init == nil {
+			// ссылочные глобальные и так равны null
+			lit, ok := zeroLiteral(g.Type)
+			if !ok {
+				continue
+			}
+			init = lit
+		}
+		body.Statements = append(body.Statements, &ast.AssignStmt{
+			Target: &ast.IdentExpr{Name: g.Name},
+			Value:  init,
+		})
+	}
+
+	c.mod.Functions[bytecode.InitFunction] = &bytecode.FunctionInfo{
+		Name:       bytecode.InitFunction,
+		ReturnType: bytecode.TypeVoid,
+	}
+	return c.compileFunction(&ast.FunctionDecl{
+		Name:       bytecode.InitFunction,
+		ReturnType: types.Type{Kind: types.TypeVoid},
+		Body:       body,
+	})
+}
+
+// zeroLiteral — начальное значение переменной примитивного типа без
+// инициализатора. null могут хранить только ссылочные типы.
+func zeroLiteral(t types.Type) (*ast.LiteralExpr, bool) {
+	switch t.Kind {
+	case types.TypeInt, types.TypeEnum:
+		return &ast.LiteralExpr{Lexeme: "0", Token: token.TokenNumber, Type: t}, true
+	case types.TypeFloat:
+		return &ast.LiteralExpr{Lexeme: "0", Token: token.TokenNumber, Type: t}, true
+	case types.TypeBool:
+		return &ast.LiteralExpr{Lexeme: "false", Token: token.TokenFalse, Type: t}, true
+	case types.TypeChar:
+		return &ast.LiteralExpr{Lexeme: "\x00", Token: token.TokenCharText, Type: t}, true
+	}
+	return nil, false
+}
+
 func (c *Compiler) compileFunction(fn *ast.FunctionDecl) error {
 
 	bfn, ok := c.mod.Functions[fn.Name]
@@ -126,28 +257,106 @@ func (c *Compiler) compileFunction(fn *ast.FunctionDecl) error {
 		bfn.ParamTypes[i] = mapTypeName(p.Type)
 	}
 
+	c.compileBody(fn, nil)
+	return nil
+}
+
+// compileBody компилирует тело в c.fn. Параметры занимают первые слоты,
+// за ними — ячейки захваченных переменных анонимной функции.
+// Захваченные параметры перекладываются в ячейки при входе.
+func (c *Compiler) compileBody(fn *ast.FunctionDecl, captures []string) {
+	ch := c.chunk()
+
 	for _, p := range fn.Params {
 		c.addLocal(p.Name, mapTypeName(p.Type))
 	}
+	for _, name := range captures {
+		slot := c.addLocal(name, bytecode.TypeInvalid)
+		c.locals[slot].cell = true
+	}
+	for i, p := range fn.Params {
+		if p.Captured {
+			ch.Write(bytecode.OpLoadLocal)
+			ch.WriteUint8(byte(i))
+			ch.Write(bytecode.OpNewCell)
+			ch.Write(bytecode.OpStoreLocal)
+			ch.WriteUint8(byte(i))
+			c.locals[i].cell = true
+		}
+	}
 
 	c.compileBlock(fn.Body)
 
-	ch := c.chunk()
 	ch.Write(bytecode.OpConst)
 	idx := ch.AddConstant(bytecode.Value{Kind: bytecode.ValNull})
 	ch.WriteUint16(uint16(idx))
 	ch.Write(bytecode.OpReturn)
+}
 
-	return nil
+// compileFuncLit компилирует тело анонимной функции в отдельную функцию
+// модуля и создает замыкание из ячеек захваченных переменных.
+func (c *Compiler) compileFuncLit(e *ast.FuncLitExpr) {
+	ch := c.chunk()
+	for _, name := range e.Captures {
+		slot, ok := c.resolveLocal(name)
+		if !ok || !c.locals[slot].cell {
+			panic("captured variable is not a cell: " + name)
+		}
+		// сама ячейка, а не ее значение
+		ch.Write(bytecode.OpLoadLocal)
+		ch.WriteUint8(byte(slot))
+	}
+
+	fn := e.Decl
+	bfn := &bytecode.FunctionInfo{
+		Name:        fn.Name,
+		ParamCount:  len(fn.Params),
+		ParamTypes:  make([]bytecode.TypeKind, len(fn.Params)),
+		ReturnType:  mapTypeName(fn.ReturnType),
+		NumUpvalues: len(e.Captures),
+	}
+	for i, p := range fn.Params {
+		bfn.ParamTypes[i] = mapTypeName(p.Type)
+	}
+	c.mod.Functions[fn.Name] = bfn
+
+	outer, locals, inlines, tries := c.fn, c.locals, c.inlines, c.tries
+	breakStack, continueStack := c.breakStack, c.continueStack
+	c.fn, c.locals, c.inlines, c.tries = bfn, nil, nil, 0
+	c.breakStack, c.continueStack = nil, nil
+
+	c.compileBody(fn, e.Captures)
+
+	c.fn, c.locals, c.inlines, c.tries = outer, locals, inlines, tries
+	c.breakStack, c.continueStack = breakStack, continueStack
+
+	c.writeClosure(fn.Name)
+}
+
+func (c *Compiler) writeClosure(name string) {
+	ch := c.chunk()
+	ch.Write(bytecode.OpClosure)
+	idx := ch.AddConstant(bytecode.Value{Kind: bytecode.ValString, S: name})
+	ch.WriteUint16(uint16(idx))
 }
 
 func (c *Compiler) compileBlock(b *ast.BlockStmt) {
+	// имена, объявленные внутри блока, не видны после него; слоты переиспользуются
+	scope := len(c.locals)
+	defer func() { c.locals = c.locals[:scope] }()
+
 	for _, stmt := range b.Statements {
 		c.compileStmt(stmt)
 	}
 }
 
 func (c *Compiler) compileStmt(s ast.Stmt) {
+	// между операторами на стеке лежат только значения под подставленным телом
+	c.depth = 0
+	if n := len(c.inlines); n > 0 {
+		c.depth = c.inlines[n-1].depth
+	}
+
 	switch st := s.(type) {
 	case *ast.VarDeclStmt:
 		c.compileVarDecl(st)
@@ -162,12 +371,21 @@ func (c *Compiler) compileStmt(s ast.Stmt) {
 		c.compileIf(st)
 	case *ast.WhileStmt:
 		c.compileWhile(st)
+	case *ast.SwitchStmt:
+		c.compileSwitch(st)
 	case *ast.ForStmt:
 		c.compileFor(st)
+	case *ast.TryStmt:
+		c.compileTry(st)
+	case *ast.ThrowStmt:
+		c.compileExpr(st.Value)
+		c.chunk().Write(bytecode.OpThrow)
 	case *ast.BreakStmt:
 		c.compileBreak(st)
 	case *ast.ContinueStmt:
 		c.compileContinue(st)
+	case *ast.BlockStmt:
+		c.compileBlock(st)
 	default:
 		panic(fmt.Sprintf("unknown stmt %T", st))
 	}
@@ -177,18 +395,29 @@ func (c *Compiler) compileVarDecl(s *ast.VarDeclStmt) {
 	ch := c.chunk()
 	typ := mapTypeName(s.Type)
 
-	if s.Init != nil {
-		c.compileExpr(s.Init)
+	init := s.Init
+	if lit, ok := zeroLiteral(s.Type); ok && init == nil {
+		init = lit
+	}
+	if init != nil {
+		c.compileExpr(init)
 	} else {
 		ch.Write(bytecode.OpConst)
 		idx := ch.AddConstant(bytecode.Value{Kind: bytecode.ValNull})
 		ch.WriteUint16(uint16(idx))
 	}
 
+	// захваченная переменная живет в ячейке: вложенная функция
+	// видит ее изменения, а она — изменения из вложенной
+	if s.Captured {
+		ch.Write(bytecode.OpNewCell)
+	}
+
 	slot := c.addLocal(s.Name, typ)
+	c.locals[slot].cell = s.Captured
 
 	ch.Write(bytecode.OpStoreLocal)
-	ch.WriteByte(byte(slot))
+	ch.WriteUint8(byte(slot))
 }
 
 func (c *Compiler) compileAssign(s *ast.AssignStmt) {
@@ -196,11 +425,20 @@ func (c *Compiler) compileAssign(s *ast.AssignStmt) {
 
 	switch target := s.Target.(type) {
 	case *ast.IdentExpr:
+		if c.compileIncrement(target, s.Value) {
+			return
+		}
 		c.compileExpr(s.Value)
 
-		if slot, ok := c.resolveLocal(target.Name); ok {
+		if slot, ok := c.resolveLocal(target.Name); ok && c.locals[slot].cell {
+			ch.Write(bytecode.OpStoreCell)
+			ch.WriteUint8(byte(slot))
+		} else if ok {
 			ch.Write(bytecode.OpStoreLocal)
-			ch.WriteByte(byte(slot))
+			ch.WriteUint8(byte(slot))
+		} else if g, ok := c.globals[target.Name]; ok {
+			ch.Write(bytecode.OpStoreGlobal)
+			ch.WriteUint16(uint16(g.slot))
 		} else {
 			panic("unknown variable " + target.Name)
 		}
@@ -208,16 +446,96 @@ func (c *Compiler) compileAssign(s *ast.AssignStmt) {
 	case *ast.IndexExpr:
 		c.compileExpr(target.Array)
 		c.compileExpr(target.Index)
-		c.compileExpr(s.Value)
-		ch.Write(bytecode.OpArraySet)
+		if s.Op != 0 {
+			// a[i] op= v: массив и индекс вычисляются один раз
+			ch.Write(bytecode.OpDup2)
+			ch.Mark(target.Pos)
+			if target.InBounds {
+				ch.Write(bytecode.OpArrayGetUnchecked)
+			} else {
+				ch.Write(bytecode.OpArrayGet)
+			}
+			c.depth++
+			c.compileExpr(s.Value)
+			c.writeBinaryOp(s.Op)
+		} else {
+			c.compileExpr(s.Value)
+		}
+		ch.Mark(target.Pos)
+		if target.InBounds {
+			ch.Write(bytecode.OpArraySetUnchecked)
+		} else {
+			ch.Write(bytecode.OpArraySet)
+		}
+
+	case *ast.FieldExpr:
+		c.compileExpr(target.Object)
+		if s.Op != 0 {
+			ch.Write(bytecode.OpDup)
+			ch.Mark(target.Pos)
+			ch.Write(bytecode.OpGetField)
+			ch.WriteUint8(byte(target.Index))
+			c.depth++
+			c.compileExpr(s.Value)
+			c.writeBinaryOp(s.Op)
+		} else {
+			c.compileExpr(s.Value)
+		}
+		ch.Mark(target.Pos)
+		ch.Write(bytecode.OpSetField)
+		ch.WriteUint8(byte(target.Index))
 
 	default:
 		panic("assignment to unsupported target")
 	}
 }
 
+// compileIncrement компилирует x = x + c и x = x - c для int-локала
+// с небольшой константой c в один OpIncLocal.
+func (c *Compiler) compileIncrement(target *ast.IdentExpr, value ast.Expr) bool {
+	slot, ok := c.resolveLocal(target.Name)
+	if !ok || c.locals[slot].typ != bytecode.TypeInt || c.locals[slot].cell {
+		return false
+	}
+	bin, ok := value.(*ast.BinaryExpr)
+	if !ok || (bin.Op != token.TokenPlus && bin.Op != token.TokenMinus) {
+		return false
+	}
+	if id, ok := bin.Left.(*ast.IdentExpr); !ok || id.Name != target.Name {
+		return false
+	}
+	lit, ok := bin.Right.(*ast.LiteralExpr)
+	if !ok || lit.Type.Kind != types.TypeInt {
+		return false
+	}
+	delta, err := strconv.ParseInt(lit.Lexeme, 10, 64)
+	if err != nil {
+		return false
+	}
+	if bin.Op == token.TokenMinus {
+		delta = -delta
+	}
+	if delta < math.MinInt8 || delta > math.MaxInt8 {
+		return false
+	}
+
+	ch := c.chunk()
+	ch.Write(bytecode.OpIncLocal)
+	ch.WriteUint8(byte(slot))
+	ch.WriteUint8(byte(int8(delta)))
+	return true
+}
+
 func (c *Compiler) compileReturn(s *ast.ReturnStmt) {
 	ch := c.chunk()
+
+	if len(c.inlines) == 0 {
+		if call, ok := s.Value.(*ast.CallExpr); ok && c.isTailCall(call) {
+			c.compileTailCall(call)
+			return
+		}
+	}
+
 	if s.Value != nil {
 
 		c.compileExpr(s.Value)
@@ -227,6 +545,14 @@ func (c *Compiler) compileReturn(s *ast.ReturnStmt) {
 		idx := ch.AddConstant(bytecode.Value{Kind: bytecode.ValNull})
 		ch.WriteUint16(uint16(idx))
 	}
+
+	if n := len(c.inlines); n > 0 {
+		// return из подставленного тела: результат уже на стеке, идем в конец подстановки
+		ch.Write(bytecode.OpJump)
+		c.inlines[n-1].returns = append(c.inlines[n-1].returns, len(ch.Code))
+		ch.WriteUint16(0)
+		return
+	}
 	ch.Write(bytecode.OpReturn)
 }
 
@@ -289,6 +615,10 @@ func (c *Compiler) compileWhile(s *ast.WhileStmt) {
 }
 
 func (c *Compiler) compileExpr(e ast.Expr) {
+	// каждое выражение оставляет на стеке ровно одно значение
+	depth := c.depth
+	defer func() { c.depth = depth + 1 }()
+
 	switch ex := e.(type) {
 	case *ast.IdentExpr:
 		c.compileIdent(ex)
@@ -303,10 +633,69 @@ func (c *Compiler) compileExpr(e ast.Expr) {
 	case *ast.IndexExpr:
 		c.compileExpr(ex.Array)
 		c.compileExpr(ex.Index)
-		c.chunk().Write(bytecode.OpArrayGet)
+		c.chunk().Mark(ex.Pos)
+		if ex.InBounds {
+			c.chunk().Write(bytecode.OpArrayGetUnchecked)
+		} else {
+			c.chunk().Write(bytecode.OpArrayGet)
+		}
 	case *ast.NewArrayExpr:
-		c.compileExpr(ex.Length)
-		c.chunk().Write(bytecode.OpArrayNew)
+		// элементы без заданного размера (new int[3][]) — null
+		fill := bytecode.Value{Kind: bytecode.ValNull}
+		if len(ex.Lengths) == ex.Dims {
+			fill = bytecode.ZeroValue(mapTypeName(ex.ElementType))
+		}
+		ch := c.chunk()
+		ch.Write(bytecode.OpConst)
+		ch.WriteUint16(uint16(ch.AddConstant(fill)))
+		c.depth++
+		for _, l := range ex.Lengths {
+			c.compileExpr(l)
+		}
+		ch.Write(bytecode.OpArrayNew)
+		ch.WriteUint16(uint16(len(ex.Lengths)))
+	case *ast.NewStructExpr:
+		if _, ok := c.mod.Structs[ex.Name]; !ok {
+			panic("unknown struct " + ex.Name)
+		}
+		ch := c.chunk()
+		ch.Write(bytecode.OpStructNew)
+		ch.WriteUint16(uint16(ch.AddConstant(bytecode.Value{Kind: bytecode.ValString, S: ex.Name})))
+	case *ast.NewCollectionExpr:
+		name := "$list.new"
+		if ex.Type.Kind == types.TypeMap {
+			name = "$map.new"
+		}
+		idx, _, _ := native.Lookup(name)
+		ch := c.chunk()
+		ch.Write(bytecode.OpCallNative)
+		ch.WriteUint16(uint16(idx))
+		ch.WriteUint8(0)
+	case *ast.FieldExpr:
+		c.compileExpr(ex.Object)
+		c.chunk().Mark(ex.Pos)
+		c.chunk().Write(bytecode.OpGetField)
+		c.chunk().WriteUint8(byte(ex.Index))
+	case *ast.CastExpr:
+		c.compileExpr(ex.Expr)
+		switch ex.Type.Kind {
+		case types.TypeInt:
+			c.chunk().Write(bytecode.OpToInt)
+		case types.TypeFloat:
+			c.chunk().Write(bytecode.OpToFloat)
+		case types.TypeChar:
+			c.chunk().Write(bytecode.OpToChar)
+		default:
+			panic("unsupported conversion to " + ex.Type.String())
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, el := range ex.Elements {
+			c.compileExpr(el)
+		}
+		c.chunk().Write(bytecode.OpArrayLiteral)
+		c.chunk().WriteUint16(uint16(len(ex.Elements)))
+	case *ast.FuncLitExpr:
+		c.compileFuncLit(ex)
 	default:
 		panic(fmt.Sprintf("unknown expr %T", ex))
 	}
@@ -315,7 +704,7 @@ func (c *Compiler) compileExpr(e ast.Expr) {
 func (c *Compiler) compileLiteral(l *ast.LiteralExpr) {
 
 	switch l.Type.Kind {
-	case types.TypeInt:
+	case types.TypeInt, types.TypeEnum:
 		c.compileInt(l)
 	case types.TypeFloat:
 		c.compileFloat(l)
@@ -346,7 +735,7 @@ func (c *Compiler) compileInt(l *ast.LiteralExpr) {
 
 func (c *Compiler) compileFloat(l *ast.LiteralExpr) {
 	ch := c.chunk()
-	floatVal, _ := strconv.ParseFloat(l.Lexeme, 32)
+	floatVal, _ := strconv.ParseFloat(l.Lexeme, 64)
 	v := bytecode.Value{Kind: bytecode.ValFloat, F: floatVal}
 
 	ch.Write(bytecode.OpConst)
@@ -395,8 +784,29 @@ func (c *Compiler) compileIdent(e *ast.IdentExpr) {
 	ch := c.chunk()
 
 	if slot, ok := c.resolveLocal(e.Name); ok {
-		ch.Write(bytecode.OpLoadLocal)
-		ch.WriteByte(byte(slot))
+		if c.locals[slot].cell {
+			ch.Write(bytecode.OpLoadCell)
+		} else {
+			ch.Write(bytecode.OpLoadLocal)
+		}
+		ch.WriteUint8(byte(slot))
+		return
+	}
+
+	if g, ok := c.globals[e.Name]; ok {
+		// значение константы подставляется прямо в код
+		if lit, ok := g.decl.Init.(*ast.LiteralExpr); ok && g.decl.Const {
+			c.compileLiteral(lit)
+			return
+		}
+		ch.Write(bytecode.OpLoadGlobal)
+		ch.WriteUint16(uint16(g.slot))
+		return
+	}
+
+	// имя функции как значение — замыкание без захваченных переменных
+	if name, ok := c.funcName(e.Name); ok {
+		c.writeClosure(name)
 		return
 	}
 
@@ -413,6 +823,8 @@ func (c *Compiler) compileUnary(e *ast.UnaryExpr) {
 		ch.Write(bytecode.OpNeg)
 	case token.TokenNot:
 		ch.Write(bytecode.OpNot)
+	case token.TokenBitNot:
+		ch.Write(bytecode.OpBitNot)
 	default:
 		panic("unknown unary op")
 	}
@@ -432,6 +844,7 @@ func (c *Compiler) compileBinary(e *ast.BinaryExpr) {
 		ch.WriteUint16(0)
 
 		ch.Write(bytecode.OpPop)
+		c.depth--
 
 		c.compileExpr(e.Right)
 
@@ -455,6 +868,7 @@ func (c *Compiler) compileBinary(e *ast.BinaryExpr) {
 		ch.PatchUint16(jumpToRight, uint16(rightPos))
 
 		ch.Write(bytecode.OpPop)
+		c.depth--
 
 		c.compileExpr(e.Right)
 
@@ -465,46 +879,61 @@ func (c *Compiler) compileBinary(e *ast.BinaryExpr) {
 	default:
 		c.compileExpr(e.Left)
 		c.compileExpr(e.Right)
+		c.writeBinaryOp(e.Op)
+	}
+}
 
-		switch e.Op {
-		case token.TokenPlus:
-			ch.Write(bytecode.OpAdd)
-		case token.TokenMinus:
-			ch.Write(bytecode.OpSub)
-		case token.TokenMultiply:
-			ch.Write(bytecode.OpMul)
-		case token.TokenDivide:
-			ch.Write(bytecode.OpDiv)
-		case token.TokenModulo:
-			ch.Write(bytecode.OpMod)
-		case token.TokenPower:
-			ch.Write(bytecode.OpPow)
-
-		case token.TokenEqual:
-			ch.Write(bytecode.OpEq)
-		case token.TokenNotEqual:
-			ch.Write(bytecode.OpNe)
-		case token.TokenLess:
-			ch.Write(bytecode.OpLt)
-		case token.TokenLessEqual:
-			ch.Write(bytecode.OpLe)
-		case token.TokenGreater:
-			ch.Write(bytecode.OpGt)
-		case token.TokenGreaterEqual:
-			ch.Write(bytecode.OpGe)
+var binaryOps = map[token.TokenType]bytecode.OpCode{
+	token.TokenPlus:     bytecode.OpAdd,
+	token.TokenMinus:    bytecode.OpSub,
+	token.TokenMultiply: bytecode.OpMul,
+	token.TokenDivide:   bytecode.OpDiv,
+	token.TokenModulo:   bytecode.OpMod,
+	token.TokenPower:    bytecode.OpPow,
+
+	token.TokenBitAnd:     bytecode.OpBitAnd,
+	token.TokenBitOr:      bytecode.OpBitOr,
+	token.TokenXor:        bytecode.OpBitXor,
+	token.TokenShiftLeft:  bytecode.OpShl,
+	token.TokenShiftRight: bytecode.OpShr,
+
+	token.TokenEqual:        bytecode.OpEq,
+	token.TokenNotEqual:     bytecode.OpNe,
+	token.TokenLess:         bytecode.OpLt,
+	token.TokenLessEqual:    bytecode.OpLe,
+	token.TokenGreater:      bytecode.OpGt,
+	token.TokenGreaterEqual: bytecode.OpGe,
+}
 
-		default:
-			panic("unknown binary op")
-		}
+func (c *Compiler) writeBinaryOp(op token.TokenType) {
+	code, ok := binaryOps[op]
+	if !ok {
+		panic("unknown binary op")
 	}
+	c.chunk().Write(code)
+}
+
+// builtinOps — встроенные функции, которые компилируются в один опкод;
+// число аргументов уже проверено семантикой.
+var builtinOps = map[string]bytecode.OpCode{
+	"len":        bytecode.OpLen,
+	"str":        bytecode.OpStr,
+	"parseInt":   bytecode.OpParseInt,
+	"parseFloat": bytecode.OpParseFloat,
 }
 
 func (c *Compiler) compileCall(e *ast.CallExpr) {
 	ch := c.chunk()
 
 	id, ok := e.Callee.(*ast.IdentExpr)
-	if !ok {
-		panic("call of non-identifier is not supported")
+	if !ok || c.isVariable(id.Name) {
+		c.compileIndirectCall(e)
+		return
+	}
+
+	if fn, ok := c.inlinable[id.Name]; ok {
+		c.compileInlineCall(fn, e)
+		return
 	}
 
 	for _, arg := range e.Args {
@@ -523,10 +952,28 @@ func (c *Compiler) compileCall(e *ast.CallExpr) {
 		ch.WriteUint16(uint16(idx))
 		return
 	}
-	_, ok = c.mod.Functions[name]
+	if op, ok := builtinOps[name]; ok {
+		ch.Mark(e.Pos)
+		ch.Write(op)
+		return
+	}
+	link, ok := c.funcName(name)
 	if !ok {
-		panic("unknown function: " + name)
+		// функция программы закрывает одноименную встроенную
+		idx, _, ok := native.Lookup(name)
+		if !ok {
+			panic("unknown function: " + name)
+		}
+		if len(e.Args) > 255 {
+			panic("too many arguments: " + name)
+		}
+		ch.Mark(e.Pos)
+		ch.Write(bytecode.OpCallNative)
+		ch.WriteUint16(uint16(idx))
+		ch.WriteUint8(byte(len(e.Args)))
+		return
 	}
+	name = link
 
 	ch.Write(bytecode.OpCall)
 
@@ -537,9 +984,75 @@ func (c *Compiler) compileCall(e *ast.CallExpr) {
 	ch.WriteUint16(uint16(idx))
 }
 
+// compileIndirectCall: значение функции, затем аргументы, OpCallIndirect.
+func (c *Compiler) compileIndirectCall(e *ast.CallExpr) {
+	c.compileExpr(e.Callee)
+	for _, arg := range e.Args {
+		c.compileExpr(arg)
+	}
+	ch := c.chunk()
+	ch.Mark(e.Pos)
+	ch.Write(bytecode.OpCallIndirect)
+	ch.WriteUint8(byte(len(e.Args)))
+}
+
+// isVariable: имя — локальная или глобальная переменная, которая
+// закрывает одноименную функцию.
+func (c *Compiler) isVariable(name string) bool {
+	if _, ok := c.resolveLocal(name); ok {
+		return true
+	}
+	_, ok := c.globals[name]
+	return ok
+}
+
+// isTailCall: return f(args), где f — обычная функция модуля
+// (не builtin и не подставляемая).
+func (c *Compiler) isTailCall(e *ast.CallExpr) bool {
+	id, ok := e.Callee.(*ast.IdentExpr)
+	if !ok || c.tries > 0 || c.isVariable(id.Name) {
+		return false
+	}
+	if _, ok := c.inlinable[id.Name]; ok {
+		return false
+	}
+	_, ok = c.funcName(id.Name)
+	return ok
+}
+
+// funcName — имя функции в модуле; у импортированной это имя, под которым
+// ее тело окажется после линковки.
+func (c *Compiler) funcName(name string) (string, bool) {
+	if link, ok := c.externs[name]; ok {
+		return link, true
+	}
+	_, ok := c.mod.Functions[name]
+	return name, ok
+}
+
+func (c *Compiler) compileTailCall(e *ast.CallExpr) {
+	ch := c.chunk()
+
+	for _, arg := range e.Args {
+		c.compileExpr(arg)
+	}
+
+	name, _ := c.funcName(e.Callee.(*ast.IdentExpr).Name)
+	ch.Write(bytecode.OpTailCall)
+	idx := ch.AddConstant(bytecode.Value{
+		Kind: bytecode.ValString,
+		S:    name,
+	})
+	ch.WriteUint16(uint16(idx))
+}
+
 func (c *Compiler) compileFor(s *ast.ForStmt) {
 	ch := c.chunk()
 
+	// переменная из init живет только внутри for
+	scope := len(c.locals)
+	defer func() { c.locals = c.locals[:scope] }()
+
 	if s.Init != nil {
 		c.compileStmt(s.Init)
 	}
@@ -585,6 +1098,203 @@ func (c *Compiler) compileFor(s *ast.ForStmt) {
 	}
 }
 
+// compileTry: тело, за ним ветки catch. Исключение из тела VM по таблице
+// обработчиков передает в ветку, подходящую по типу, со значением на стеке.
+func (c *Compiler) compileTry(s *ast.TryStmt) {
+	ch := c.chunk()
+
+	depth := c.depth
+	start := len(ch.Code)
+	c.tries++
+	c.compileBlock(s.Body)
+	c.tries--
+	end := len(ch.Code)
+
+	// переход в конец после тела и после каждой ветки, кроме последней
+	var exits []int
+	handlers := make([]bytecode.Handler, 0, len(s.Catches))
+	for _, cc := range s.Catches {
+		ch.Write(bytecode.OpJump)
+		exits = append(exits, len(ch.Code))
+		ch.WriteUint16(0)
+
+		h := bytecode.Handler{Start: start, End: end, Target: len(ch.Code), Type: bytecode.TypeAny, Depth: depth}
+		scope := len(c.locals)
+		if cc.Name == "" {
+			ch.Write(bytecode.OpPop)
+		} else {
+			h.Type = mapTypeName(cc.Type)
+			if cc.Type.Kind == types.TypeStruct {
+				h.Struct = cc.Type.Name
+			}
+			if cc.Captured {
+				ch.Write(bytecode.OpNewCell)
+			}
+			slot := c.addLocal(cc.Name, h.Type)
+			c.locals[slot].cell = cc.Captured
+			ch.Write(bytecode.OpStoreLocal)
+			ch.WriteUint8(byte(slot))
+		}
+		handlers = append(handlers, h)
+
+		c.compileBlock(cc.Body)
+		c.locals = c.locals[:scope]
+	}
+
+	for _, pos := range exits {
+		ch.PatchUint16(pos, uint16(len(ch.Code)))
+	}
+	// вложенные try уже добавили свои обработчики — они раньше в таблице
+	c.fn.Handlers = append(c.fn.Handlers, handlers...)
+}
+
+// таблица переходов строится, если значений case не меньше jumpTableMin,
+// а диапазон от минимума до максимума заполнен хотя бы наполовину
+const (
+	jumpTableMin  = 3
+	jumpTableSpan = 1024
+)
+
+// compileSwitch: для плотных int-значений — один OpJumpTable, иначе
+// цепочка сравнений subject с каждым значением по порядку.
+func (c *Compiler) compileSwitch(s *ast.SwitchStmt) {
+	ch := c.chunk()
+
+	// break выходит из switch, continue относится к внешнему циклу
+	c.breakStack = append(c.breakStack, nil)
+
+	c.compileExpr(s.Subject)
+
+	values, min, span, table := denseCases(s)
+	var caseJumps [][]int // для каждой ветки — места переходов на ее тело
+	var defaultJumps []int
+
+	if table {
+		ch.Write(bytecode.OpJumpTable)
+		ch.WriteUint16(uint16(len(ch.JumpTables)))
+		ch.JumpTables = append(ch.JumpTables, bytecode.JumpTable{})
+	} else {
+		scope := len(c.locals)
+		slot := c.addLocal("", bytecode.TypeInvalid)
+		ch.Write(bytecode.OpStoreLocal)
+		ch.WriteUint8(byte(slot))
+
+		for _, cs := range s.Cases {
+			var jumps []int
+			for _, v := range cs.Values {
+				ch.Write(bytecode.OpLoadLocal)
+				ch.WriteUint8(byte(slot))
+				c.compileExpr(v)
+				ch.Write(bytecode.OpEq)
+
+				ch.Write(bytecode.OpJumpIfFalse)
+				next := len(ch.Code)
+				ch.WriteUint16(0)
+
+				ch.Write(bytecode.OpPop)
+				ch.Write(bytecode.OpJump)
+				jumps = append(jumps, len(ch.Code))
+				ch.WriteUint16(0)
+
+				ch.PatchUint16(next, uint16(len(ch.Code)))
+				ch.Write(bytecode.OpPop)
+			}
+			caseJumps = append(caseJumps, jumps)
+		}
+		ch.Write(bytecode.OpJump)
+		defaultJumps = append(defaultJumps, len(ch.Code))
+		ch.WriteUint16(0)
+		c.locals = c.locals[:scope]
+	}
+
+	starts := make([]int, len(s.Cases))
+	var endJumps []int
+	for i, cs := range s.Cases {
+		starts[i] = len(ch.Code)
+		c.compileBlock(cs.Body)
+		ch.Write(bytecode.OpJump)
+		endJumps = append(endJumps, len(ch.Code))
+		ch.WriteUint16(0)
+	}
+	defaultStart := len(ch.Code)
+	if s.Default != nil {
+		c.compileBlock(s.Default)
+	}
+	end := len(ch.Code)
+
+	if table {
+		t := &ch.JumpTables[len(ch.JumpTables)-1]
+		t.Min = min
+		t.Default = defaultStart
+		t.Targets = make([]int, span)
+		for i := range t.Targets {
+			t.Targets[i] = defaultStart
+		}
+		// обратный порядок: при повторе значения выигрывает первая ветка
+		for i := len(values) - 1; i >= 0; i-- {
+			for _, v := range values[i] {
+				t.Targets[v-min] = starts[i]
+			}
+		}
+	} else {
+		for i, jumps := range caseJumps {
+			for _, pos := range jumps {
+				ch.PatchUint16(pos, uint16(starts[i]))
+			}
+		}
+		for _, pos := range defaultJumps {
+			ch.PatchUint16(pos, uint16(defaultStart))
+		}
+	}
+	for _, pos := range endJumps {
+		ch.PatchUint16(pos, uint16(end))
+	}
+
+	bi := len(c.breakStack) - 1
+	for _, pos := range c.breakStack[bi] {
+		ch.PatchUint16(pos, uint16(end))
+	}
+	c.breakStack = c.breakStack[:bi]
+}
+
+// denseCases возвращает значения веток, если все они — целые литералы
+// (константы перечислений тоже целые)
+// и их достаточно плотно, чтобы строить таблицу переходов.
+func denseCases(s *ast.SwitchStmt) ([][]int64, int64, int, bool) {
+	values := make([][]int64, len(s.Cases))
+	count := 0
+	var min, max int64
+	for i, cs := range s.Cases {
+		for _, e := range cs.Values {
+			lit, ok := e.(*ast.LiteralExpr)
+			if !ok || (lit.Type.Kind != types.TypeInt && lit.Type.Kind != types.TypeEnum) {
+				return nil, 0, 0, false
+			}
+			v, err := strconv.ParseInt(lit.Lexeme, 10, 64)
+			if err != nil {
+				return nil, 0, 0, false
+			}
+			if count == 0 || v < min {
+				min = v
+			}
+			if count == 0 || v > max {
+				max = v
+			}
+			count++
+			values[i] = append(values[i], v)
+		}
+	}
+	if count < jumpTableMin {
+		return nil, 0, 0, false
+	}
+	// разница может переполниться для далеких значений
+	span := uint64(max-min) + 1
+	if span > jumpTableSpan || span > 2*uint64(count) {
+		return nil, 0, 0, false
+	}
+	return values, min, int(span), true
+}
+
 func (c *Compiler) beginLoop() {
 	c.breakStack = append(c.breakStack, nil)
 	c.continueStack = append(c.continueStack, nil)
@@ -608,7 +1318,7 @@ func (c *Compiler) endLoop(continueTarget, breakTarget int) {
 
 func (c *Compiler) compileBreak(_ *ast.BreakStmt) {
 	if len(c.breakStack) == 0 {
-		panic("break outside of loop")
+		panic("break outside of loop or switch")
 	}
 	ch := c.chunk()
 	ch.Write(bytecode.OpJump)
@@ -0,0 +1,68 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNumericConversions(t *testing.T) {
+	res, err := run(t, `
+function half(float x) float {
+    return x / 2.0
+}
+
+function test() int {
+    int i = 7
+    float f = float(i) / 2.0 + 1
+    char c = char(int('a') + 1)
+    float h = half(i)
+    return int(f * 10.0) * 1000 + int(c) + int(h * 10.0) * 1000000 + int(-3.9)
+}
+`)
+	// f = 4.5, c = 'b' (98), h = 3.5, int(-3.9) = -3
+	if err != nil || res.I != 35045095 {
+		t.Fatalf("test() = %d, %v; want 35045095", res.I, err)
+	}
+}
+
+func TestConversionRangeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"float out of int range", `
+function test() int {
+    float big = 10000000000000000000.0 * 10.0
+    return int(big)
+}
+`, "out of int range"},
+		{"int out of char range", `
+function test() char {
+    int n = 300
+    return char(n)
+}
+`, "out of char range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrayLiteralPromotion(t *testing.T) {
+	res, err := run(t, `
+function test() float {
+    float[] a = [1, 2.5]
+    float[] b = [3, 4]
+    return a[0] / 2.0 + a[1] + b[0] / 2.0
+}
+`)
+	if err != nil || res.F != 4.5 {
+		t.Fatalf("test() = %g, %v; want 4.5", res.F, err)
+	}
+}
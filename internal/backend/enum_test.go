@@ -0,0 +1,77 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+const enumSrc = `
+enum Color { Red, Green, Blue }
+
+function name(Color c) string {
+    switch (c) {
+    case Color.Red:
+        return "r"
+    case Color.Green:
+        return "g"
+    case Color.Blue:
+        return "b"
+    }
+    return "?"
+}
+
+function test() string {
+    Color c = Color.Blue
+    Color[] cs = new Color[2]
+    cs[1] = Color.Green
+    string s = str(c) + " " + str(cs[0]) + " " + name(cs[1]) + str(int(c))
+    if (cs[0] == Color.Red && c != cs[1]) {
+        s += " ok"
+    }
+    println(c, cs[1])
+    return s
+}
+`
+
+func TestEnums(t *testing.T) {
+	mod, _ := compile(t, enumSrc, false)
+	if got := opCounts(t, mod, "name")[bytecode.OpJumpTable]; got != 1 {
+		t.Fatalf("name has %d OpJumpTable, want 1", got)
+	}
+
+	res, out, err := runIO(t, enumSrc, "")
+	if err != nil || res.S != "Blue Red g2 ok" {
+		t.Fatalf("test() = %q, %v; want %q", res.S, err, "Blue Red g2 ok")
+	}
+	if out != "Blue Green\n" {
+		t.Fatalf("output %q, want %q", out, "Blue Green\n")
+	}
+}
+
+func TestImportedEnum(t *testing.T) {
+	got := runFiles(t, map[string]string{
+		"main.easy": `
+import "colors.easy"
+
+function test() int {
+    Color c = colors.favorite()
+    if (c == Color.Green) return int(c)
+    return -1
+}
+
+function main() void {
+}
+`,
+		"colors.easy": `
+enum Color { Red, Green }
+
+export function favorite() Color {
+    return Color.Green
+}
+`,
+	})
+	if got != 1 {
+		t.Fatalf("test() = %d, want 1", got)
+	}
+}
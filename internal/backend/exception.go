@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+// Exception — значение, брошенное throw и не пойманное ни одним фреймом.
+type Exception struct {
+	Value bytecode.Value
+}
+
+func (e *Exception) Error() string {
+	return "uncaught exception: " + bytecode.FormatValue(e.Value)
+}
+
+// NullReference — обращение через null: индекс, поле, вызов функции,
+// len или встроенная функция, получившая null вместо массива, строки
+// или коллекции.
+type NullReference struct {
+	Message  string
+	Function string
+	Pos      int // позиция в исходнике, -1 — неизвестна
+}
+
+func (e *NullReference) Error() string {
+	if e.Pos < 0 {
+		return fmt.Sprintf("[NullReference] %s in function %s", e.Message, e.Function)
+	}
+	return fmt.Sprintf("[NullReference] %s in function %s at pos %d", e.Message, e.Function, e.Pos)
+}
+
+// nullReference — ошибка инструкции по смещению at функции fn.
+func nullReference(fn *bytecode.FunctionInfo, at int, message string) error {
+	pos, ok := fn.Chunk.PosAt(at)
+	if !ok {
+		pos = -1
+	}
+	return &NullReference{Message: message, Function: fn.Name, Pos: pos}
+}
+
+// uncheckedFault превращает панику индексации в OpArrayGetUnchecked или
+// OpArraySetUnchecked в ошибку с позицией; остальные паники не ловятся.
+// Такая ошибка не перехватывается try в той же функции, только выше.
+func uncheckedFault(fn *bytecode.FunctionInfo, at int, r any) error {
+	re, ok := r.(runtime.Error)
+	if !ok || at >= len(fn.Chunk.Code) {
+		panic(r)
+	}
+	name := "array get"
+	switch bytecode.OpCode(fn.Chunk.Code[at]) {
+	case bytecode.OpArrayGetUnchecked:
+	case bytecode.OpArraySetUnchecked:
+		name = "array set"
+	default:
+		panic(r)
+	}
+	msg := fmt.Sprintf("%s: %s in function %s", name, strings.TrimPrefix(re.Error(), "runtime error: "), fn.Name)
+	if pos, ok := fn.Chunk.PosAt(at); ok {
+		msg += fmt.Sprintf(" at pos %d", pos)
+	}
+	return errors.New(msg)
+}
+
+// exceptionValue — значение, которое получает ветка catch: брошенное throw
+// или текст ошибки VM (деление на ноль, выход за границы и т. п.).
+func exceptionValue(err error) bytecode.Value {
+	var exc *Exception
+	if errors.As(err, &exc) {
+		return exc.Value
+	}
+	return bytecode.Value{Kind: bytecode.ValString, S: err.Error()}
+}
+
+// findHandler — первый обработчик fn, чей диапазон содержит ip и тип
+// подходит под значение.
+func findHandler(fn *bytecode.FunctionInfo, ip int, v bytecode.Value) (bytecode.Handler, bool) {
+	for _, h := range fn.Handlers {
+		if ip >= h.Start && ip < h.End && catches(h, v) {
+			return h, true
+		}
+	}
+	return bytecode.Handler{}, false
+}
+
+func catches(h bytecode.Handler, v bytecode.Value) bool {
+	switch h.Type {
+	case bytecode.TypeAny:
+		return true
+	case bytecode.TypeInt:
+		return v.Kind == bytecode.ValInt
+	case bytecode.TypeFloat:
+		return v.Kind == bytecode.ValFloat
+	case bytecode.TypeBool:
+		return v.Kind == bytecode.ValBool
+	case bytecode.TypeChar:
+		return v.Kind == bytecode.ValChar
+	case bytecode.TypeString:
+		return v.Kind == bytecode.ValString
+	case bytecode.TypeStruct:
+		return v.Kind == bytecode.ValObject && v.Obj != nil && v.Obj.Type == bytecode.ObjStruct &&
+			v.Obj.Struct != nil && v.Obj.Struct.Name == h.Struct
+	}
+	return false
+}
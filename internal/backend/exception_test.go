@@ -0,0 +1,146 @@
+package backend_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+func TestThrowUnwindsFrames(t *testing.T) {
+	res, err := run(t, `
+struct Err { int code }
+
+function fail(int kind) int {
+    if (kind == 0) throw 7
+    if (kind == 1) throw "bad"
+    if (kind == 2) {
+        Err e = new Err
+        e.code = 40
+        throw e
+    }
+    if (kind == 3) {
+        int zero = 0
+        return 1 / zero
+    }
+    throw true
+}
+
+function deep(int kind, int depth) int {
+    if (depth == 0) return fail(kind)
+    return deep(kind, depth - 1) + 1
+}
+
+function classify(int kind) int {
+    try {
+        return deep(kind, 5)
+    } catch (int n) {
+        return n
+    } catch (string s) {
+        if (s == "bad") return 20
+        return 30
+    } catch (Err e) {
+        return e.code
+    } catch {
+        return 50
+    }
+}
+
+function test() int {
+    int s = 0
+    for (int k = 0; k < 5; k++) {
+        s = s * 100 + classify(k)
+    }
+    return s
+}
+`)
+	// 7, "bad" -> 20, Err -> 40, деление на ноль -> 30, bool -> 50
+	if err != nil || res.I != 720403050 {
+		t.Fatalf("test() = %d, %v; want 720403050", res.I, err)
+	}
+}
+
+func TestNestedTryRethrow(t *testing.T) {
+	res, err := run(t, `
+function test() int {
+    int log = 0
+    try {
+        try {
+            throw 1
+        } catch (string s) {
+            log = 100
+        }
+    } catch (int n) {
+        log = log + n * 10
+        try {
+            throw n + 1
+        } catch (int m) {
+            log = log + m
+        }
+    }
+    return log
+}
+`)
+	if err != nil || res.I != 12 {
+		t.Fatalf("test() = %d, %v; want 12", res.I, err)
+	}
+}
+
+func TestUncaughtException(t *testing.T) {
+	_, err := run(t, `
+function inner() int {
+    throw "boom"
+}
+
+function test() int {
+    try {
+        return inner()
+    } catch (int n) {
+        return n
+    }
+}
+`)
+	var exc *backend.Exception
+	if !errors.As(err, &exc) || exc.Value.Kind != bytecode.ValString || exc.Value.S != "boom" {
+		t.Fatalf("err = %v, want uncaught string exception", err)
+	}
+	if err.Error() != `uncaught exception: boom` {
+		t.Fatalf("message %q", err.Error())
+	}
+}
+
+func TestInlinedTryKeepsOperands(t *testing.T) {
+	mod, comp := compile(t, `
+function safe(int a, int b) int {
+    try {
+        return a / b
+    } catch {
+        return 0
+    }
+}
+
+function test() int {
+    int[] xs = [1, 2, 3]
+    int s = 0
+    try {
+        s = 10 + xs[1] * safe(6, 3) * 100 + safe(1, 0)
+        s = s + safe(5, 0) + xs[safe(4, 2)]
+    } catch {
+        return -1
+    }
+    return s
+}
+`, true)
+	reports := comp.InlineReports()
+	if len(reports) != 1 || reports[0].Inlined["safe"] != 4 {
+		t.Fatalf("reports = %v, want safe inlined 4 times", reports)
+	}
+	res, err := call(mod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.I != 413 {
+		t.Fatalf("test() = %d, want 413", res.I)
+	}
+}
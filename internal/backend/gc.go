@@ -35,6 +35,9 @@ func (vm *VM) gc() {
 }
 
 func (vm *VM) markRoots() {
+	for i := range vm.globals {
+		vm.markValue(&vm.globals[i])
+	}
 	for _, r := range vm.roots {
 		if r.locals != nil {
 			for i := range *r.locals {
@@ -63,10 +66,17 @@ func (vm *VM) markObject(o *bytecode.Object) {
 	o.Mark = true
 
 	switch o.Type {
-	case bytecode.ObjArray:
+	case bytecode.ObjArray, bytecode.ObjList, bytecode.ObjStruct, bytecode.ObjClosure, bytecode.ObjUpvalue:
+		// элементы списка, поля структуры, ячейки замыкания и значение
+		// ячейки хранятся в Items так же, как элементы массива
 		for i := range o.Items {
 			vm.markValue(&o.Items[i])
 		}
+	case bytecode.ObjMap:
+		// ключи — значения сравнимых типов, ссылок в них нет
+		for _, v := range o.Map {
+			vm.markValue(&v)
+		}
 	}
 }
 
@@ -0,0 +1,65 @@
+package backend_test
+
+import "testing"
+
+func TestGenericFunctions(t *testing.T) {
+	res, err := run(t, `
+function max<T: ordered>(T a, T b) T {
+    if (a > b) return a
+    return b
+}
+
+function sort<T: ordered>(T[] arr) void {
+    for (int i = 0; i < len(arr); i++) {
+        for (int j = 0; j + 1 < len(arr) - i; j++) {
+            if (arr[j] > arr[j + 1]) {
+                T tmp = arr[j]
+                arr[j] = arr[j + 1]
+                arr[j + 1] = tmp
+            }
+        }
+    }
+}
+
+function map<T, U>(T[] a, function(T) U f) U[] {
+    U[] out = new U[len(a)]
+    for (int i = 0; i < len(a); i++) {
+        out[i] = f(a[i])
+    }
+    return out
+}
+
+function test() string {
+    string[] words = ["pear", "fig", "apple"]
+    sort(words)
+    int[] lens = map(words, function(string w) int { return len(w) })
+    float f = max(1, 2.5)
+    return words[0] + words[2] + str(lens[1]) + str(max(3, 7)) + max("b", "a") + str(f)
+}
+`)
+	if err != nil || res.S != "applepear37b2.5" {
+		t.Fatalf("test() = %q, %v; want %q", res.S, err, "applepear37b2.5")
+	}
+}
+
+func TestGenericComparableEnum(t *testing.T) {
+	res, err := run(t, `
+enum Color { Red, Green, Blue }
+
+function eq<T: comparable>(T a, T b) bool {
+    return a == b
+}
+
+function test() int {
+    Color c = Color.Blue
+    int n = 0
+    if (eq(Color.Red, Color.Red)) n = n + 1
+    if (eq(Color.Red, c)) n = n + 10
+    if (eq(c, Color.Blue)) n = n + 100
+    return n
+}
+`)
+	if err != nil || res.I != 101 {
+		t.Fatalf("test() = %d, %v; want 101", res.I, err)
+	}
+}
@@ -0,0 +1,31 @@
+package backend_test
+
+import "testing"
+
+func TestGlobals(t *testing.T) {
+	res, err := run(t, `
+const int N = 4
+const int M = N * 2
+int counter = M
+int[] data = new int[N]
+
+function bump() void {
+    counter = counter + 1
+}
+
+function test() int {
+    bump()
+    bump()
+    data[N - 1] = 7
+    int counter = 100
+    return counter + globalCounter() * 10 + data[3]
+}
+
+function globalCounter() int {
+    return counter
+}
+`)
+	if err != nil || res.I != 207 {
+		t.Fatalf("test() = %d, %v; want 207", res.I, err)
+	}
+}
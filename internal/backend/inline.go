@@ -0,0 +1,279 @@
+package backend
+
+import (
+	"sort"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+)
+
+// максимальный размер тела (в узлах AST), который еще подставляется
+const inlineMaxNodes = 40
+
+// inlineFrame — состояние подстановки одного вызова.
+type inlineFrame struct {
+	base    int   // первый локал подставленной функции в c.locals
+	depth   int   // значений вызывающей функции на стеке под телом
+	returns []int // позиции аргументов OpJump для return внутри тела
+}
+
+type InlineReport struct {
+	Function string
+	Inlined  map[string]int // имя подставленной функции -> число мест вызова
+}
+
+func (r InlineReport) Callees() []string {
+	names := make([]string, 0, len(r.Inlined))
+	for name := range r.Inlined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InlineReports возвращает отчеты в порядке объявления функций,
+// только для функций, в которые что-то было подставлено.
+func (c *Compiler) InlineReports() []InlineReport {
+	var out []InlineReport
+	for _, name := range c.order {
+		if inlined, ok := c.inlined[name]; ok {
+			out = append(out, InlineReport{Function: name, Inlined: inlined})
+		}
+	}
+	return out
+}
+
+// findInlinable выбирает небольшие функции, которые не участвуют
+// в рекурсии (ни прямой, ни взаимной) и не содержат анонимных функций:
+// тело анонимной функции компилируется один раз, под своим именем.
+func findInlinable(p *ast.Program) map[string]*ast.FunctionDecl {
+	decls := make(map[string]*ast.FunctionDecl, len(p.Functions))
+	for _, fn := range p.Functions {
+		if fn.Extern == "" {
+			decls[fn.Name] = fn
+		}
+	}
+
+	calls := make(map[string][]string, len(p.Functions))
+	for _, fn := range decls {
+		collectCalls(fn.Body, decls, func(name string) {
+			calls[fn.Name] = append(calls[fn.Name], name)
+		})
+	}
+
+	out := make(map[string]*ast.FunctionDecl)
+	for _, fn := range decls {
+		if fn.Name == "main" || countNodes(fn.Body) > inlineMaxNodes || hasFuncLit(fn.Body) {
+			continue
+		}
+		if reaches(calls, fn.Name, fn.Name, map[string]bool{}) {
+			continue
+		}
+		out[fn.Name] = fn
+	}
+	return out
+}
+
+func reaches(calls map[string][]string, from, to string, seen map[string]bool) bool {
+	for _, next := range calls[from] {
+		if next == to {
+			return true
+		}
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		if reaches(calls, next, to, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func collectCalls(block *ast.BlockStmt, decls map[string]*ast.FunctionDecl, visit func(string)) {
+	walkBlock(block, func(e ast.Expr) {
+		call, ok := e.(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		if id, ok := call.Callee.(*ast.IdentExpr); ok {
+			if _, ok := decls[id.Name]; ok {
+				visit(id.Name)
+			}
+		}
+	})
+}
+
+func hasFuncLit(block *ast.BlockStmt) bool {
+	found := false
+	walkBlock(block, func(e ast.Expr) {
+		if _, ok := e.(*ast.FuncLitExpr); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+func countNodes(block *ast.BlockStmt) int {
+	n := 0
+	walkBlock(block, func(ast.Expr) { n++ })
+	walkStmts(block, func(ast.Stmt) { n++ })
+	return n
+}
+
+func walkStmts(block *ast.BlockStmt, visit func(ast.Stmt)) {
+	if block == nil {
+		return
+	}
+	for _, stmt := range block.Statements {
+		walkStmt(stmt, visit)
+	}
+}
+
+func walkStmt(stmt ast.Stmt, visit func(ast.Stmt)) {
+	visit(stmt)
+	switch s := stmt.(type) {
+	case *ast.IfStmt:
+		walkStmts(s.ThenBlock, visit)
+		walkStmts(s.ElseBlock, visit)
+	case *ast.WhileStmt:
+		walkStmts(s.Body, visit)
+	case *ast.SwitchStmt:
+		for _, b := range s.Blocks() {
+			walkStmts(b, visit)
+		}
+	case *ast.ForStmt:
+		if s.Init != nil {
+			walkStmt(s.Init, visit)
+		}
+		if s.Increment != nil {
+			walkStmt(s.Increment, visit)
+		}
+		walkStmts(s.Body, visit)
+	case *ast.TryStmt:
+		walkStmts(s.Body, visit)
+		for _, c := range s.Catches {
+			walkStmts(c.Body, visit)
+		}
+	case *ast.BlockStmt:
+		walkStmts(s, visit)
+	}
+}
+
+// walkBlock обходит все выражения блока, включая вложенные.
+func walkBlock(block *ast.BlockStmt, visit func(ast.Expr)) {
+	walkStmts(block, func(stmt ast.Stmt) {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			walkExpr(s.Init, visit)
+		case *ast.AssignStmt:
+			walkExpr(s.Target, visit)
+			walkExpr(s.Value, visit)
+		case *ast.ExprStmt:
+			walkExpr(s.Expr, visit)
+		case *ast.ReturnStmt:
+			walkExpr(s.Value, visit)
+		case *ast.ThrowStmt:
+			walkExpr(s.Value, visit)
+		case *ast.IfStmt:
+			walkExpr(s.Condition, visit)
+		case *ast.WhileStmt:
+			walkExpr(s.Condition, visit)
+		case *ast.SwitchStmt:
+			walkExpr(s.Subject, visit)
+			for _, c := range s.Cases {
+				for _, v := range c.Values {
+					walkExpr(v, visit)
+				}
+			}
+		case *ast.ForStmt:
+			walkExpr(s.Condition, visit)
+		}
+	})
+}
+
+func walkExpr(expr ast.Expr, visit func(ast.Expr)) {
+	if expr == nil {
+		return
+	}
+	visit(expr)
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		walkExpr(e.Left, visit)
+		walkExpr(e.Right, visit)
+	case *ast.UnaryExpr:
+		walkExpr(e.Expr, visit)
+	case *ast.CallExpr:
+		walkExpr(e.Callee, visit)
+		for _, arg := range e.Args {
+			walkExpr(arg, visit)
+		}
+	case *ast.IndexExpr:
+		walkExpr(e.Array, visit)
+		walkExpr(e.Index, visit)
+	case *ast.NewArrayExpr:
+		for _, l := range e.Lengths {
+			walkExpr(l, visit)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, el := range e.Elements {
+			walkExpr(el, visit)
+		}
+	case *ast.FieldExpr:
+		walkExpr(e.Object, visit)
+	case *ast.CastExpr:
+		walkExpr(e.Expr, visit)
+	case *ast.FuncLitExpr:
+		walkBlock(e.Decl.Body, visit)
+	}
+}
+
+// compileInlineCall подставляет тело fn на место вызова. Параметры и
+// локалы получают свежие слоты вызывающей функции, return превращается
+// в переход на конец подстановки с результатом на стеке.
+func (c *Compiler) compileInlineCall(fn *ast.FunctionDecl, e *ast.CallExpr) {
+	ch := c.chunk()
+	depth := c.depth
+
+	for _, arg := range e.Args {
+		c.compileExpr(arg)
+	}
+
+	scope := len(c.locals)
+	slots := make([]int, len(fn.Params))
+	for i, p := range fn.Params {
+		slots[i] = c.addLocal(p.Name, mapTypeName(p.Type))
+	}
+	for i := len(slots) - 1; i >= 0; i-- {
+		ch.Write(bytecode.OpStoreLocal)
+		ch.WriteUint8(byte(slots[i]))
+	}
+
+	frame := &inlineFrame{base: scope, depth: depth}
+	c.inlines = append(c.inlines, frame)
+
+	// break/continue тела не должны видеть циклы вызывающей функции
+	breakStack, continueStack := c.breakStack, c.continueStack
+	c.breakStack, c.continueStack = nil, nil
+
+	c.compileBlock(fn.Body)
+
+	// тело закончилось без return — результат null
+	ch.Write(bytecode.OpConst)
+	idx := ch.AddConstant(bytecode.Value{Kind: bytecode.ValNull})
+	ch.WriteUint16(uint16(idx))
+
+	end := len(ch.Code)
+	for _, pos := range frame.returns {
+		ch.PatchUint16(pos, uint16(end))
+	}
+
+	c.breakStack, c.continueStack = breakStack, continueStack
+	c.inlines = c.inlines[:len(c.inlines)-1]
+	c.locals = c.locals[:scope]
+
+	if c.inlined[c.fn.Name] == nil {
+		c.inlined[c.fn.Name] = make(map[string]int)
+	}
+	c.inlined[c.fn.Name][fn.Name]++
+}
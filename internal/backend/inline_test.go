@@ -0,0 +1,74 @@
+package backend_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+const inlineSrc = `
+function sq(int x) int {
+    return x * x
+}
+
+function fac(int n) int {
+    if (n <= 1) {
+        return 1
+    }
+    return n * fac(n - 1)
+}
+
+function isEven(int n) bool {
+    if (n == 0) {
+        return true
+    }
+    return isOdd(n - 1)
+}
+
+function isOdd(int n) bool {
+    if (n == 0) {
+        return false
+    }
+    return isEven(n - 1)
+}
+
+function test() int {
+    int r = sq(3) + sq(4) + fac(5)
+    if (isEven(10)) {
+        r = r + 1
+    }
+    return r
+}
+`
+
+func TestInlineSmallFunctions(t *testing.T) {
+	mod, comp := compile(t, inlineSrc, true)
+
+	reports := comp.InlineReports()
+	if len(reports) != 1 || reports[0].Function != "test" {
+		t.Fatalf("reports = %v, want only test", reports)
+	}
+	// fac, isEven и isOdd рекурсивны — подставляется только sq
+	if want := map[string]int{"sq": 2}; !maps.Equal(reports[0].Inlined, want) {
+		t.Fatalf("inlined into test = %v, want %v", reports[0].Inlined, want)
+	}
+	if got := opCounts(t, mod, "test")[bytecode.OpCall]; got != 2 {
+		t.Fatalf("test has %d calls, want 2 (fac and isEven)", got)
+	}
+
+	res, err := call(mod)
+	if err != nil || res.I != 146 {
+		t.Fatalf("test() = %d, %v; want 146", res.I, err)
+	}
+}
+
+func TestInlineDisabled(t *testing.T) {
+	mod, comp := compile(t, inlineSrc, false)
+	if reports := comp.InlineReports(); len(reports) != 0 {
+		t.Fatalf("reports = %v, want none", reports)
+	}
+	if got := opCounts(t, mod, "test")[bytecode.OpCall]; got != 4 {
+		t.Fatalf("test has %d calls, want 4", got)
+	}
+}
@@ -0,0 +1,126 @@
+package backend_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+// runIO вызывает test() с заданным вводом и возвращает вывод программы.
+func runIO(t *testing.T, src, input string) (bytecode.Value, string, error) {
+	t.Helper()
+	mod, _ := compile(t, src, false)
+	var out bytes.Buffer
+	vm := backend.NewVM(mod, true)
+	vm.SetOutput(&out)
+	vm.SetInput(strings.NewReader(input))
+	res, err := vm.Call("test", nil)
+	return res, out.String(), err
+}
+
+func TestPrintFunctions(t *testing.T) {
+	_, out, err := runIO(t, `
+function test() void {
+    print(1)
+    print("a")
+    println()
+    println("sum:", 2 + 3, 2.5, true, 'c')
+    printf("%d items, %.2f avg, %5s|%-3c|%x %t %v %%\n", 3, 1.0 / 3.0, "ab", 'z', 255, false, "v")
+    println("tab\there")
+}
+`, "")
+	want := "1 a \nsum: 5 2.5 true c\n3 items, 0.33 avg,    ab|z  |ff false v %\ntab\there\n"
+	if err != nil || out != want {
+		t.Fatalf("output %q, %v; want %q", out, err, want)
+	}
+}
+
+func TestPrintfErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		call string
+		want string
+	}{
+		{"verb mismatch", `printf("%d", "x")`, "%d"},
+		{"missing argument", `printf("%d %d", 1)`, "printf"},
+		{"extra argument", `printf("%d", 1, 2)`, "printf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, _, err := runIO(t, `
+function test() string {
+    try {
+        `+tt.call+`
+    } catch (string msg) {
+        return msg
+    }
+    return "no error"
+}
+`, "")
+			if err != nil || !strings.Contains(res.S, tt.want) || res.S == "no error" {
+				t.Fatalf("test() = %q, %v; want error about %q", res.S, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadInput(t *testing.T) {
+	res, _, err := runIO(t, `
+function test() int {
+    string first = readLine()
+    int a = readInt()
+    int b = readInt()
+    string rest = readLine()
+    string end = readLine()
+    if (end != null) return -1
+    return len(first) * 10000 + a * 100 + b + len(rest) * 1000000
+}
+`, "hello\n 12\t30 tail\n")
+	// first = "hello", a = 12, b = 30; пробел после 30 съеден, rest = "tail"
+	if err != nil || res.I != 4051230 {
+		t.Fatalf("test() = %d, %v; want 4051230", res.I, err)
+	}
+
+	_, _, err = runIO(t, `
+function test() int {
+    return readInt()
+}
+`, "  ")
+	if err == nil {
+		t.Fatalf("readInt at end of input succeeded")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(in, []byte("line1\nline2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.txt")
+
+	res, _, err := runIO(t, `
+function test() int {
+    string text = readFile("`+in+`")
+    writeFile("`+out+`", text + "!")
+    try {
+        readFile("`+filepath.Join(dir, "missing.txt")+`")
+    } catch (string msg) {
+        return len(text)
+    }
+    return -1
+}
+`, "")
+	if err != nil || res.I != 11 {
+		t.Fatalf("test() = %d, %v; want 11", res.I, err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || string(data) != "line1\nline2!" {
+		t.Fatalf("out.txt = %q, %v", data, err)
+	}
+}
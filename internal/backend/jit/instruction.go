@@ -15,14 +15,32 @@ func Decode(code []byte, ip int) (Instruction, bool) {
 
 	OpCode := bytecode.OpCode(code[ip])
 	switch OpCode {
-	case bytecode.OpConst, bytecode.OpJump, bytecode.OpJumpIfFalse, bytecode.OpCall:
+	case bytecode.OpConst, bytecode.OpJump, bytecode.OpJumpIfFalse, bytecode.OpCall, bytecode.OpTailCall,
+		bytecode.OpArrayNew, bytecode.OpArrayLiteral, bytecode.OpStructNew,
+		bytecode.OpLoadGlobal, bytecode.OpStoreGlobal, bytecode.OpJumpTable, bytecode.OpClosure:
 		if ip+2 >= len(code) {
 			return Instruction{}, false
 		}
 		Argument := int(uint16(code[ip+1])<<8 | uint16(code[ip+2]))
 		return Instruction{OpCode: OpCode, Argument: Argument, Size: 3}, true
 
-	case bytecode.OpLoadLocal, bytecode.OpStoreLocal:
+	case bytecode.OpIncLocal:
+		// Argument — слот, шаг читается отдельно
+		if ip+2 >= len(code) {
+			return Instruction{}, false
+		}
+		return Instruction{OpCode: OpCode, Argument: int(code[ip+1]), Size: 3}, true
+
+	case bytecode.OpCallNative:
+		// Argument — номер функции, число аргументов читается отдельно
+		if ip+3 >= len(code) {
+			return Instruction{}, false
+		}
+		Argument := int(uint16(code[ip+1])<<8 | uint16(code[ip+2]))
+		return Instruction{OpCode: OpCode, Argument: Argument, Size: 4}, true
+
+	case bytecode.OpLoadLocal, bytecode.OpStoreLocal, bytecode.OpGetField, bytecode.OpSetField,
+		bytecode.OpLoadCell, bytecode.OpStoreCell, bytecode.OpCallIndirect:
 		if ip+1 >= len(code) {
 			return Instruction{}, false
 		}
@@ -4,9 +4,16 @@ import "github.com/ChernykhITMO/compiler/internal/bytecode"
 
 func OpCodeSizeByte(op bytecode.OpCode) int {
 	switch op {
-	case bytecode.OpConst, bytecode.OpJump, bytecode.OpJumpIfFalse, bytecode.OpCall:
+	case bytecode.OpConst, bytecode.OpJump, bytecode.OpJumpIfFalse, bytecode.OpCall, bytecode.OpTailCall,
+		bytecode.OpArrayNew, bytecode.OpArrayLiteral, bytecode.OpStructNew,
+		bytecode.OpLoadGlobal, bytecode.OpStoreGlobal, bytecode.OpJumpTable, bytecode.OpClosure:
 		return 1 + 2
-	case bytecode.OpLoadLocal, bytecode.OpStoreLocal:
+	case bytecode.OpIncLocal:
+		return 1 + 2
+	case bytecode.OpCallNative:
+		return 1 + 3
+	case bytecode.OpLoadLocal, bytecode.OpStoreLocal, bytecode.OpGetField, bytecode.OpSetField,
+		bytecode.OpLoadCell, bytecode.OpStoreCell, bytecode.OpCallIndirect:
 		return 1 + 1
 	default:
 		return 1
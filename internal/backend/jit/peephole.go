@@ -60,6 +60,16 @@ func OptimizePeephole(fn *bytecode.FunctionInfo) {
 		ip += size
 	}
 
+	tables, ok := relocateJumpTables(ch.JumpTables, oldToNewIPMap)
+	if !ok {
+		return
+	}
+	handlers, ok := relocateHandlers(fn.Handlers, oldToNewIPMap)
+	if !ok {
+		return
+	}
+	positions := relocatePositions(ch.Positions, reps, oldToNewIPMap)
+
 	// сборка нового кода и изменение jump target
 	out := make([]byte, 0, newIP)
 	replaceIndex = 0
@@ -77,7 +87,9 @@ func OptimizePeephole(fn *bytecode.FunctionInfo) {
 		out = append(out, byte(op))
 
 		switch op {
-		case bytecode.OpConst, bytecode.OpCall:
+		case bytecode.OpConst, bytecode.OpCall, bytecode.OpTailCall, bytecode.OpArrayNew, bytecode.OpArrayLiteral,
+			bytecode.OpStructNew, bytecode.OpLoadGlobal, bytecode.OpStoreGlobal, bytecode.OpIncLocal,
+			bytecode.OpJumpTable, bytecode.OpClosure:
 			if ip+1 >= len(code) {
 				ch.Code = out
 				return
@@ -85,6 +97,14 @@ func OptimizePeephole(fn *bytecode.FunctionInfo) {
 			out = append(out, code[ip], code[ip+1])
 			ip += 2
 
+		case bytecode.OpCallNative:
+			if ip+2 >= len(code) {
+				ch.Code = out
+				return
+			}
+			out = append(out, code[ip], code[ip+1], code[ip+2])
+			ip += 3
+
 		case bytecode.OpJump, bytecode.OpJumpIfFalse:
 			if ip+1 >= len(code) {
 				ch.Code = out
@@ -101,7 +121,8 @@ func OptimizePeephole(fn *bytecode.FunctionInfo) {
 
 			out = append(out, byte(uint16(newTargetIP)>>8), byte(uint16(newTargetIP)))
 
-		case bytecode.OpLoadLocal, bytecode.OpStoreLocal:
+		case bytecode.OpLoadLocal, bytecode.OpStoreLocal, bytecode.OpGetField, bytecode.OpSetField,
+			bytecode.OpLoadCell, bytecode.OpStoreCell, bytecode.OpCallIndirect:
 			if ip >= len(code) {
 				ch.Code = out
 				return
@@ -112,6 +133,69 @@ func OptimizePeephole(fn *bytecode.FunctionInfo) {
 	}
 
 	ch.Code = out
+	ch.JumpTables = tables
+	ch.Positions = positions
+	fn.Handlers = handlers
+}
+
+// relocateJumpTables переводит адреса таблиц switch в новый код.
+func relocateJumpTables(tables []bytecode.JumpTable, oldToNewIPMap map[int]int) ([]bytecode.JumpTable, bool) {
+	out := make([]bytecode.JumpTable, len(tables))
+	for i, t := range tables {
+		def, ok := oldToNewIPMap[t.Default]
+		if !ok {
+			return nil, false
+		}
+		targets := make([]int, len(t.Targets))
+		for j, target := range t.Targets {
+			if targets[j], ok = oldToNewIPMap[target]; !ok {
+				return nil, false
+			}
+		}
+		out[i] = bytecode.JumpTable{Min: t.Min, Targets: targets, Default: def}
+	}
+	return out, true
+}
+
+// relocateHandlers переводит диапазоны и адреса обработчиков исключений
+// в новый код.
+func relocateHandlers(handlers []bytecode.Handler, oldToNewIPMap map[int]int) ([]bytecode.Handler, bool) {
+	out := make([]bytecode.Handler, len(handlers))
+	for i, h := range handlers {
+		start, ok1 := oldToNewIPMap[h.Start]
+		end, ok2 := oldToNewIPMap[h.End]
+		target, ok3 := oldToNewIPMap[h.Target]
+		if !ok1 || !ok2 || !ok3 {
+			return nil, false
+		}
+		out[i] = bytecode.Handler{Start: start, End: end, Target: target, Type: h.Type, Struct: h.Struct, Depth: h.Depth}
+	}
+	return out, true
+}
+
+// relocatePositions переводит позиции в исходнике в новый код. Замена
+// заканчивается слитой инструкцией, она получает первую позицию
+// из замененного участка.
+func relocatePositions(positions []bytecode.Position, reps []replacementCode, oldToNewIPMap map[int]int) []bytecode.Position {
+	out := make([]bytecode.Position, 0, len(positions))
+	r := 0
+	for _, p := range positions {
+		for r < len(reps) && reps[r].oldEndIP <= p.Offset {
+			r++
+		}
+		if r < len(reps) && reps[r].oldStartIP <= p.Offset {
+			rep := reps[r]
+			fused := oldToNewIPMap[rep.oldStartIP] + len(rep.newCode) - 1
+			if len(out) == 0 || out[len(out)-1].Offset != fused {
+				out = append(out, bytecode.Position{Offset: fused, Pos: p.Pos})
+			}
+			continue
+		}
+		if newIP, ok := oldToNewIPMap[p.Offset]; ok {
+			out = append(out, bytecode.Position{Offset: newIP, Pos: p.Pos})
+		}
+	}
+	return out
 }
 
 func matchBytecodeSwap(code []byte, start int) (bool, []byte, int) {
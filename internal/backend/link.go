@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/ChernykhITMO/compiler/internal/backend/jit"
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+// Unit — скомпилированный файл программы. Функции и глобальные переменные
+// получают при линковке префикс Name + "."; у корневого файла Name пустое.
+type Unit struct {
+	Name   string
+	Module *bytecode.Module
+}
+
+// Link собирает единицы в один модуль. Единицы идут в порядке зависимостей,
+// в нем же выполняются инициализаторы их глобальных переменных.
+// Вызовы функций других модулей уже записаны под именами после линковки,
+// переименовываются только собственные функции единицы.
+func Link(units []Unit) (*bytecode.Module, error) {
+	out := &bytecode.Module{
+		Functions: make(map[string]*bytecode.FunctionInfo),
+		Structs:   make(map[string]*bytecode.StructInfo),
+	}
+	var inits []string
+
+	for i, u := range units {
+		prefix := ""
+		if u.Name != "" {
+			prefix = u.Name + "."
+		}
+
+		// структуры общие: импортирующий файл компилирует копии объявлений модуля
+		for name, s := range u.Module.Structs {
+			if prev, ok := out.Structs[name]; ok {
+				if !sameStruct(prev, s) {
+					return nil, fmt.Errorf("link: struct %s is defined differently in several files", name)
+				}
+				continue
+			}
+			out.Structs[name] = s
+		}
+
+		base := len(out.Globals)
+		for _, g := range u.Module.Globals {
+			out.Globals = append(out.Globals, prefix+g)
+		}
+
+		names := make(map[string]string, len(u.Module.Functions))
+		for name := range u.Module.Functions {
+			names[name] = prefix + name
+		}
+		if _, ok := u.Module.Functions[bytecode.InitFunction]; ok {
+			names[bytecode.InitFunction] = bytecode.InitFunction + "#" + strconv.Itoa(i)
+			inits = append(inits, names[bytecode.InitFunction])
+		}
+
+		for name, fn := range u.Module.Functions {
+			if err := relocate(fn, names, base); err != nil {
+				return nil, err
+			}
+			fn.Name = names[name]
+			if _, ok := out.Functions[fn.Name]; ok {
+				return nil, fmt.Errorf("link: duplicate function: %s", fn.Name)
+			}
+			out.Functions[fn.Name] = fn
+		}
+	}
+
+	if len(inits) > 0 {
+		out.Functions[bytecode.InitFunction] = initChain(inits)
+	}
+	return out, nil
+}
+
+// relocate переписывает имена вызываемых функций единицы и сдвигает
+// номера слотов ее глобальных переменных на base.
+func relocate(fn *bytecode.FunctionInfo, names map[string]string, base int) error {
+	ch := &fn.Chunk
+	for ip := 0; ip < len(ch.Code); {
+		ins, ok := jit.Decode(ch.Code, ip)
+		if !ok {
+			return fmt.Errorf("link: %s: truncated instruction at %d", fn.Name, ip)
+		}
+		switch ins.OpCode {
+		case bytecode.OpCall, bytecode.OpTailCall, bytecode.OpClosure:
+			c := &ch.Constants[ins.Argument]
+			if name, ok := names[c.S]; ok {
+				c.S = name
+			}
+		case bytecode.OpLoadGlobal, bytecode.OpStoreGlobal:
+			slot := ins.Argument + base
+			if slot > 0xFFFF {
+				return fmt.Errorf("link: too many globals")
+			}
+			ch.Code[ip+1] = byte(slot >> 8)
+			ch.Code[ip+2] = byte(slot)
+		}
+		ip += ins.Size
+	}
+	return nil
+}
+
+// initChain — общая bytecode.InitFunction: по очереди вызывает
+// инициализаторы единиц.
+func initChain(inits []string) *bytecode.FunctionInfo {
+	fn := &bytecode.FunctionInfo{
+		Name:       bytecode.InitFunction,
+		ReturnType: bytecode.TypeVoid,
+	}
+	ch := &fn.Chunk
+	for _, name := range inits {
+		ch.Write(bytecode.OpCall)
+		ch.WriteUint16(uint16(ch.AddConstant(bytecode.Value{Kind: bytecode.ValString, S: name})))
+		ch.Write(bytecode.OpPop)
+	}
+	ch.Write(bytecode.OpConst)
+	ch.WriteUint16(uint16(ch.AddConstant(bytecode.Value{Kind: bytecode.ValNull})))
+	ch.Write(bytecode.OpReturn)
+	return fn
+}
+
+func sameStruct(a, b *bytecode.StructInfo) bool {
+	return slices.Equal(a.Fields, b.Fields) && slices.Equal(a.FieldTypes, b.FieldTypes)
+}
@@ -0,0 +1,108 @@
+package backend_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+	"github.com/ChernykhITMO/compiler/internal/frontend/loader"
+	"github.com/ChernykhITMO/compiler/internal/frontend/semantics"
+	"github.com/ChernykhITMO/compiler/internal/optimizer"
+)
+
+// runFiles загружает main.easy из files, компилирует каждый файл отдельно,
+// линкует и вызывает test(), как cmd/app с -file.
+func runFiles(t *testing.T, files map[string]string) int64 {
+	t.Helper()
+	l := loader.NewLoader()
+	l.ReadFile = func(name string) ([]byte, error) {
+		src, ok := files[name]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		return []byte(src), nil
+	}
+	units, err := l.Load("main.easy")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	var linked []backend.Unit
+	for _, u := range units {
+		if errs := semantics.NewChecker().Check(u.Program); len(errs) > 0 {
+			t.Fatalf("check %s: [%s] %s", u.Path, errs[0].Type, errs[0].Message)
+		}
+		if errs := semantics.NewASTValidator().Validate(u.Program); len(errs) > 0 {
+			t.Fatalf("validate %s: [%s] %s", u.Path, errs[0].Type, errs[0].Message)
+		}
+		optimizer.NewOptimizer().Optimize(u.Program)
+		mod, err := backend.NewCompiler(true).CompileProgram(u.Program)
+		if err != nil {
+			t.Fatalf("compile %s: %v", u.Path, err)
+		}
+		linked = append(linked, backend.Unit{Name: u.Name, Module: mod})
+	}
+	mod, err := backend.Link(linked)
+	if err != nil {
+		t.Fatalf("link: %v", err)
+	}
+
+	res, err := call(mod)
+	if err != nil {
+		t.Fatalf("test(): %v", err)
+	}
+	return res.I
+}
+
+func TestLinkModules(t *testing.T) {
+	got := runFiles(t, map[string]string{
+		"main.easy": `
+import "lib/counter.easy"
+import "lib/math.easy" as m
+
+int calls = 100
+
+function helper() int {
+    return 1
+}
+
+function test() int {
+    counter.bump()
+    counter.bump()
+    return counter.get() * 1000 + m.twice(calls) + helper()
+}
+
+function main() void {
+}
+`,
+		"lib/counter.easy": `
+int calls = 0
+
+function helper() int {
+    return 1
+}
+
+export function bump() void {
+    calls = calls + helper()
+}
+
+export function get() int {
+    return calls
+}
+`,
+		"lib/math.easy": `
+import "counter.easy"
+
+int base = counter.get() + 5
+
+export function twice(int x) int {
+    return base + x * 2
+}
+`,
+	})
+	// два вызова bump в модуле counter, calls корневого файла — 100,
+	// base модуля math инициализируется после counter: 0 + 5
+	if got != 2206 {
+		t.Fatalf("test() = %d, want 2206", got)
+	}
+}
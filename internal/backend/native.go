@@ -0,0 +1,51 @@
+package backend
+
+import (
+	"bufio"
+	"io"
+	"math/rand/v2"
+
+	"github.com/ChernykhITMO/compiler/internal/backend/native"
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+// vmEnv дает встроенным функциям кучу и генератор случайных чисел VM.
+type vmEnv struct {
+	vm *VM
+}
+
+var _ native.Env = vmEnv{}
+
+func (e vmEnv) NewArray(items []bytecode.Value) bytecode.Value {
+	obj := e.vm.newObject(bytecode.ObjArray)
+	obj.Items = items
+	return bytecode.Value{Kind: bytecode.ValObject, Obj: obj}
+}
+
+func (e vmEnv) NewList(items []bytecode.Value) bytecode.Value {
+	obj := e.vm.newObject(bytecode.ObjList)
+	obj.Items = items
+	return bytecode.Value{Kind: bytecode.ValObject, Obj: obj}
+}
+
+func (e vmEnv) NewMap() bytecode.Value {
+	obj := e.vm.newObject(bytecode.ObjMap)
+	obj.Map = make(map[bytecode.Value]bytecode.Value)
+	return bytecode.Value{Kind: bytecode.ValObject, Obj: obj}
+}
+
+func (e vmEnv) Rand() *rand.Rand {
+	return e.vm.rng
+}
+
+func (e vmEnv) Seed(seed uint64) {
+	e.vm.SetRandomSeed(seed)
+}
+
+func (e vmEnv) Output() io.Writer {
+	return e.vm.out
+}
+
+func (e vmEnv) Input() *bufio.Reader {
+	return e.vm.in
+}
@@ -0,0 +1,57 @@
+package native
+
+import (
+	"slices"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+func init() {
+	elem := types.ParamOf("T", "any")
+	ord := types.ParamOf("T", "ordered")
+
+	Register(&Func{
+		Name:       "copy",
+		TypeParams: []types.Type{elem},
+		ParamNames: []string{"a"},
+		Type:       types.FuncOf([]types.Type{types.ArrayOf(elem)}, types.ArrayOf(elem)),
+		Impl: func(env Env, args []bytecode.Value) (bytecode.Value, error) {
+			items, err := array("copy", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			return env.NewArray(slices.Clone(items)), nil
+		},
+	})
+	Register(&Func{
+		Name:       "fill",
+		TypeParams: []types.Type{elem},
+		ParamNames: []string{"a", "v"},
+		Type:       types.FuncOf([]types.Type{types.ArrayOf(elem), elem}, voidType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			items, err := array("fill", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			for i := range items {
+				items[i] = args[1]
+			}
+			return null(), nil
+		},
+	})
+	Register(&Func{
+		Name:       "sort",
+		TypeParams: []types.Type{ord},
+		ParamNames: []string{"a"},
+		Type:       types.FuncOf([]types.Type{types.ArrayOf(ord)}, voidType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			items, err := array("sort", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			slices.SortStableFunc(items, compare)
+			return null(), nil
+		},
+	})
+}
@@ -0,0 +1,240 @@
+package native
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+// Методы list<T> и map<K, V>. Семантика переписывает xs.push(v) в вызов
+// $list.push(xs, v), имя с $ из программы недоступно.
+func init() {
+	t := types.ParamOf("T", "any")
+	k := types.ParamOf("K", "comparable")
+	v := types.ParamOf("V", "any")
+	list := types.ListOf(t)
+	dict := types.MapOf(k, v)
+	boolType := types.Type{Kind: types.TypeBool}
+
+	Register(&Func{
+		Name:       "$list.new",
+		TypeParams: []types.Type{t},
+		Type:       types.FuncOf(nil, list),
+		Impl: func(env Env, _ []bytecode.Value) (bytecode.Value, error) {
+			return env.NewList(nil), nil
+		},
+	})
+	Register(&Func{
+		Name:       "$list.push",
+		TypeParams: []types.Type{t},
+		ParamNames: []string{"list", "value"},
+		Type:       types.FuncOf([]types.Type{list, t}, voidType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("push", args[0], bytecode.ObjList)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			obj.Items = append(obj.Items, args[1])
+			return null(), nil
+		},
+	})
+	Register(&Func{
+		Name:       "$list.pop",
+		TypeParams: []types.Type{t},
+		ParamNames: []string{"list"},
+		Type:       types.FuncOf([]types.Type{list}, t),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("pop", args[0], bytecode.ObjList)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			n := len(obj.Items)
+			if n == 0 {
+				return bytecode.Value{}, fmt.Errorf("pop: list is empty")
+			}
+			last := obj.Items[n-1]
+			// освобожденная ячейка не должна держать объект от сборщика
+			obj.Items[n-1] = bytecode.Value{}
+			obj.Items = obj.Items[:n-1]
+			return last, nil
+		},
+	})
+	Register(&Func{
+		Name:       "$list.get",
+		TypeParams: []types.Type{t},
+		ParamNames: []string{"list", "index"},
+		Type:       types.FuncOf([]types.Type{list, intType}, t),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("get", args[0], bytecode.ObjList)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			i, err := listIndex("get", obj, args[1])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			return obj.Items[i], nil
+		},
+	})
+	Register(&Func{
+		Name:       "$list.set",
+		TypeParams: []types.Type{t},
+		ParamNames: []string{"list", "index", "value"},
+		Type:       types.FuncOf([]types.Type{list, intType, t}, voidType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("set", args[0], bytecode.ObjList)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			i, err := listIndex("set", obj, args[1])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			obj.Items[i] = args[2]
+			return null(), nil
+		},
+	})
+
+	Register(&Func{
+		Name:       "$map.new",
+		TypeParams: []types.Type{k, v},
+		Type:       types.FuncOf(nil, dict),
+		Impl: func(env Env, _ []bytecode.Value) (bytecode.Value, error) {
+			return env.NewMap(), nil
+		},
+	})
+	Register(&Func{
+		Name:       "$map.get",
+		TypeParams: []types.Type{k, v},
+		ParamNames: []string{"map", "key"},
+		Type:       types.FuncOf([]types.Type{dict, k}, v),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("get", args[0], bytecode.ObjMap)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			val, ok := obj.Map[mapKey(args[1])]
+			if !ok {
+				return bytecode.Value{}, fmt.Errorf("get: key %s not found", bytecode.FormatValue(args[1]))
+			}
+			return val, nil
+		},
+	})
+	Register(&Func{
+		Name:       "$map.set",
+		TypeParams: []types.Type{k, v},
+		ParamNames: []string{"map", "key", "value"},
+		Type:       types.FuncOf([]types.Type{dict, k, v}, voidType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("set", args[0], bytecode.ObjMap)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			obj.Map[mapKey(args[1])] = args[2]
+			return null(), nil
+		},
+	})
+	Register(&Func{
+		Name:       "$map.has",
+		TypeParams: []types.Type{k, v},
+		ParamNames: []string{"map", "key"},
+		Type:       types.FuncOf([]types.Type{dict, k}, boolType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("has", args[0], bytecode.ObjMap)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			_, ok := obj.Map[mapKey(args[1])]
+			return bytecode.Value{Kind: bytecode.ValBool, B: ok}, nil
+		},
+	})
+	Register(&Func{
+		Name:       "$map.delete",
+		TypeParams: []types.Type{k, v},
+		ParamNames: []string{"map", "key"},
+		Type:       types.FuncOf([]types.Type{dict, k}, voidType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("delete", args[0], bytecode.ObjMap)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			delete(obj.Map, mapKey(args[1]))
+			return null(), nil
+		},
+	})
+	Register(&Func{
+		Name:       "$map.keys",
+		TypeParams: []types.Type{k, v},
+		ParamNames: []string{"map"},
+		Type:       types.FuncOf([]types.Type{dict}, types.ListOf(k)),
+		Impl: func(env Env, args []bytecode.Value) (bytecode.Value, error) {
+			obj, err := collection("keys", args[0], bytecode.ObjMap)
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			keys := make([]bytecode.Value, 0, len(obj.Map))
+			for key := range obj.Map {
+				keys = append(keys, key)
+			}
+			// порядок обхода map в Go случаен, программе нужен один и тот же
+			slices.SortFunc(keys, compareKeys)
+			return env.NewList(keys), nil
+		},
+	})
+}
+
+// collection возвращает объект списка или словаря; null — ошибка.
+func collection(name string, v bytecode.Value, t bytecode.ObjectType) (*bytecode.Object, error) {
+	kind := "list"
+	if t == bytecode.ObjMap {
+		kind = "map"
+	}
+	if v.Kind == bytecode.ValNull {
+		return nil, &NullError{Func: name, What: kind}
+	}
+	if v.Kind != bytecode.ValObject || v.Obj == nil || v.Obj.Type != t {
+		return nil, fmt.Errorf("%s: value is not %s", name, kind)
+	}
+	return v.Obj, nil
+}
+
+func listIndex(name string, obj *bytecode.Object, v bytecode.Value) (int, error) {
+	if v.I < 0 || v.I >= int64(len(obj.Items)) {
+		return 0, fmt.Errorf("%s: index %d out of range [0,%d)", name, v.I, len(obj.Items))
+	}
+	return int(v.I), nil
+}
+
+// mapKey оставляет в ключе только поле его вида: остальные поля Value
+// могут хранить мусор от прежних значений и мешать сравнению.
+func mapKey(v bytecode.Value) bytecode.Value {
+	key := bytecode.Value{Kind: v.Kind}
+	switch v.Kind {
+	case bytecode.ValInt:
+		key.I = v.I
+	case bytecode.ValFloat:
+		key.F = v.F
+	case bytecode.ValBool:
+		key.B = v.B
+	case bytecode.ValString:
+		key.S = v.S
+	case bytecode.ValChar:
+		key.C = v.C
+	}
+	return key
+}
+
+func compareKeys(a, b bytecode.Value) int {
+	if a.Kind == bytecode.ValBool {
+		switch {
+		case a.B == b.B:
+			return 0
+		case b.B:
+			return -1
+		}
+		return 1
+	}
+	return compare(a, b)
+}
@@ -0,0 +1,218 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+func init() {
+	Register(&Func{
+		Name:       "println",
+		ParamNames: []string{},
+		Type:       types.FuncOf(nil, voidType),
+		Variadic:   "any",
+		Impl: func(env Env, args []bytecode.Value) (bytecode.Value, error) {
+			parts := make([]string, len(args))
+			for i, v := range args {
+				parts[i] = bytecode.FormatValue(v)
+			}
+			if _, err := io.WriteString(env.Output(), strings.Join(parts, " ")+"\n"); err != nil {
+				return bytecode.Value{}, fmt.Errorf("println: %w", err)
+			}
+			return null(), nil
+		},
+	})
+	Register(&Func{
+		Name:       "printf",
+		ParamNames: []string{"format"},
+		Type:       types.FuncOf([]types.Type{stringType}, voidType),
+		Variadic:   "any",
+		Impl: func(env Env, args []bytecode.Value) (bytecode.Value, error) {
+			format, err := str("printf", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			s, err := sprintf(format, args[1:])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			if _, err := io.WriteString(env.Output(), s); err != nil {
+				return bytecode.Value{}, fmt.Errorf("printf: %w", err)
+			}
+			return null(), nil
+		},
+	})
+	Register(&Func{
+		Name:       "readLine",
+		ParamNames: []string{},
+		Type:       types.FuncOf(nil, stringType),
+		Impl: func(env Env, _ []bytecode.Value) (bytecode.Value, error) {
+			line, err := env.Input().ReadString('\n')
+			switch {
+			case errors.Is(err, io.EOF) && line == "":
+				return null(), nil
+			case err != nil && !errors.Is(err, io.EOF):
+				return bytecode.Value{}, fmt.Errorf("readLine: %w", err)
+			}
+			return stringValue(strings.TrimRight(line, "\r\n")), nil
+		},
+	})
+	Register(&Func{
+		Name:       "readInt",
+		ParamNames: []string{},
+		Type:       types.FuncOf(nil, intType),
+		Impl: func(env Env, _ []bytecode.Value) (bytecode.Value, error) {
+			word, err := readWord(env)
+			if err != nil {
+				return bytecode.Value{}, fmt.Errorf("readInt: %w", err)
+			}
+			i, err := strconv.ParseInt(word, 10, 64)
+			if err != nil {
+				return bytecode.Value{}, fmt.Errorf("readInt: invalid integer %q", word)
+			}
+			return intValue(i), nil
+		},
+	})
+	Register(&Func{
+		Name:       "readFile",
+		ParamNames: []string{"path"},
+		Type:       types.FuncOf([]types.Type{stringType}, stringType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			path, err := str("readFile", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return bytecode.Value{}, fmt.Errorf("readFile: %w", err)
+			}
+			return stringValue(string(data)), nil
+		},
+	})
+	Register(&Func{
+		Name:       "writeFile",
+		ParamNames: []string{"path", "data"},
+		Type:       types.FuncOf([]types.Type{stringType, stringType}, voidType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			path, err := str("writeFile", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			data, err := str("writeFile", args[1])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+				return bytecode.Value{}, fmt.Errorf("writeFile: %w", err)
+			}
+			return null(), nil
+		},
+	})
+}
+
+// readWord пропускает пробельные символы и читает слово до следующего.
+func readWord(env Env) (string, error) {
+	in := env.Input()
+	var b strings.Builder
+	for {
+		c, err := in.ReadByte()
+		if errors.Is(err, io.EOF) && b.Len() > 0 {
+			return b.String(), nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errors.New("end of input")
+			}
+			return "", err
+		}
+		if unicode.IsSpace(rune(c)) {
+			if b.Len() > 0 {
+				return b.String(), nil
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+}
+
+// sprintf разбирает формат сам: у каждого глагола значение проверяется
+// по виду, а флаги, ширина и точность передаются в fmt.
+// Глаголы: %d, %x, %f, %e, %g, %s, %c, %t, %v и %%.
+func sprintf(format string, args []bytecode.Value) (string, error) {
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j == len(format) {
+			return "", fmt.Errorf("printf: unfinished verb %q", format[i:])
+		}
+		spec, verb := format[i:j+1], format[j]
+		i = j
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if next == len(args) {
+			return "", fmt.Errorf("printf: missing argument for %s", spec)
+		}
+		arg, err := formatArg(verb, args[next])
+		if err != nil {
+			return "", fmt.Errorf("printf: %s: %w", spec, err)
+		}
+		next++
+		fmt.Fprintf(&b, spec, arg)
+	}
+	if next < len(args) {
+		return "", fmt.Errorf("printf: %d extra argument(s)", len(args)-next)
+	}
+	return b.String(), nil
+}
+
+// formatArg — значение для fmt под глагол verb.
+func formatArg(verb byte, v bytecode.Value) (any, error) {
+	switch verb {
+	case 'v':
+		return bytecode.FormatValue(v), nil
+	case 'd', 'x':
+		if v.Kind == bytecode.ValInt {
+			return v.I, nil
+		}
+	case 'f', 'e', 'g':
+		switch v.Kind {
+		case bytecode.ValFloat:
+			return v.F, nil
+		case bytecode.ValInt:
+			return float64(v.I), nil
+		}
+	case 's':
+		if v.Kind == bytecode.ValString {
+			return v.S, nil
+		}
+	case 'c':
+		if v.Kind == bytecode.ValChar {
+			return rune(v.C), nil
+		}
+	case 't':
+		if v.Kind == bytecode.ValBool {
+			return v.B, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown verb %%%c", verb)
+	}
+	return nil, fmt.Errorf("cannot format %s", bytecode.FormatValue(v))
+}
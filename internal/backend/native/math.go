@@ -0,0 +1,96 @@
+package native
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+func init() {
+	num := types.ParamOf("T", "number")
+	ord := types.ParamOf("T", "ordered")
+
+	Register(&Func{
+		Name:       "sqrt",
+		ParamNames: []string{"x"},
+		Type:       types.FuncOf([]types.Type{floatType}, floatType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			if args[0].F < 0 {
+				return bytecode.Value{}, fmt.Errorf("sqrt: negative argument %g", args[0].F)
+			}
+			return floatValue(math.Sqrt(args[0].F)), nil
+		},
+	})
+	Register(&Func{
+		Name:       "abs",
+		TypeParams: []types.Type{num},
+		ParamNames: []string{"x"},
+		Type:       types.FuncOf([]types.Type{num}, num),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			v := args[0]
+			switch {
+			case v.Kind == bytecode.ValFloat:
+				return floatValue(math.Abs(v.F)), nil
+			case v.I == math.MinInt64:
+				return bytecode.Value{}, fmt.Errorf("abs: integer overflow")
+			case v.I < 0:
+				return intValue(-v.I), nil
+			}
+			return v, nil
+		},
+	})
+	Register(&Func{
+		Name:       "min",
+		TypeParams: []types.Type{ord},
+		ParamNames: []string{"a", "b"},
+		Type:       types.FuncOf([]types.Type{ord, ord}, ord),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			if compare(args[1], args[0]) < 0 {
+				return args[1], nil
+			}
+			return args[0], nil
+		},
+	})
+	Register(&Func{
+		Name:       "max",
+		TypeParams: []types.Type{ord},
+		ParamNames: []string{"a", "b"},
+		Type:       types.FuncOf([]types.Type{ord, ord}, ord),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			if compare(args[1], args[0]) > 0 {
+				return args[1], nil
+			}
+			return args[0], nil
+		},
+	})
+	Register(&Func{
+		Name:       "floor",
+		ParamNames: []string{"x"},
+		Type:       types.FuncOf([]types.Type{floatType}, intType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			f := math.Floor(args[0].F)
+			if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return bytecode.Value{}, fmt.Errorf("floor: %g out of int range", args[0].F)
+			}
+			return intValue(int64(f)), nil
+		},
+	})
+}
+
+// compare упорядочивает значения одного вида: int, float, char или string.
+func compare(a, b bytecode.Value) int {
+	switch a.Kind {
+	case bytecode.ValInt:
+		return cmp.Compare(a.I, b.I)
+	case bytecode.ValFloat:
+		return cmp.Compare(a.F, b.F)
+	case bytecode.ValChar:
+		return cmp.Compare(a.C, b.C)
+	case bytecode.ValString:
+		return cmp.Compare(a.S, b.S)
+	}
+	return 0
+}
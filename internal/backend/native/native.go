@@ -0,0 +1,122 @@
+package native
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+// Env — то, что встроенной функции нужно от VM.
+type Env interface {
+	// NewArray создает массив в куче VM, чтобы его видел сборщик мусора.
+	NewArray(items []bytecode.Value) bytecode.Value
+	NewList(items []bytecode.Value) bytecode.Value
+	NewMap() bytecode.Value
+	Rand() *rand.Rand
+	Seed(seed uint64)
+	Output() io.Writer
+	Input() *bufio.Reader
+}
+
+// Func — встроенная функция, реализованная на Go. Аргументы уже проверены
+// семантикой по сигнатуре: int, переданный в float, продвинут.
+type Func struct {
+	Name       string
+	TypeParams []types.Type // параметры типа обобщенной функции, Kind == TypeParam
+	ParamNames []string     // для сообщений об ошибках
+	Type       types.Type   // Kind == TypeFunction
+	Variadic   string       // ограничение на аргументы после Type.Params; "" — их нет
+	Impl       func(env Env, args []bytecode.Value) (bytecode.Value, error)
+}
+
+var (
+	funcs   []*Func
+	byIndex = make(map[string]int)
+)
+
+// Register добавляет функцию в реестр; номер в реестре — первый операнд
+// OpCallNative.
+func Register(f *Func) {
+	if _, ok := byIndex[f.Name]; ok {
+		panic(fmt.Sprintf("native: %s registered twice", f.Name))
+	}
+	if len(f.ParamNames) != len(f.Type.Params) {
+		panic(fmt.Sprintf("native: %s: %d parameter names for %d parameters", f.Name, len(f.ParamNames), len(f.Type.Params)))
+	}
+	byIndex[f.Name] = len(funcs)
+	funcs = append(funcs, f)
+}
+
+func Lookup(name string) (int, *Func, bool) {
+	i, ok := byIndex[name]
+	if !ok {
+		return 0, nil, false
+	}
+	return i, funcs[i], true
+}
+
+func Get(i int) (*Func, bool) {
+	if i < 0 || i >= len(funcs) {
+		return nil, false
+	}
+	return funcs[i], true
+}
+
+var (
+	intType    = types.Type{Kind: types.TypeInt}
+	floatType  = types.Type{Kind: types.TypeFloat}
+	stringType = types.Type{Kind: types.TypeString}
+	voidType   = types.Type{Kind: types.TypeVoid}
+)
+
+func null() bytecode.Value {
+	return bytecode.Value{Kind: bytecode.ValNull}
+}
+
+func intValue(i int64) bytecode.Value {
+	return bytecode.Value{Kind: bytecode.ValInt, I: i}
+}
+
+func floatValue(f float64) bytecode.Value {
+	return bytecode.Value{Kind: bytecode.ValFloat, F: f}
+}
+
+func stringValue(s string) bytecode.Value {
+	return bytecode.Value{Kind: bytecode.ValString, S: s}
+}
+
+// NullError — аргумент встроенной функции равен null. VM превращает ее
+// в ошибку NullReference с местом вызова.
+type NullError struct {
+	Func string
+	What string // array, string, list, map
+}
+
+func (e *NullError) Error() string {
+	return fmt.Sprintf("%s: %s is null", e.Func, e.What)
+}
+
+// array возвращает элементы массива; null и не массив — ошибка.
+func array(name string, v bytecode.Value) ([]bytecode.Value, error) {
+	if v.Kind == bytecode.ValNull {
+		return nil, &NullError{Func: name, What: "array"}
+	}
+	if v.Kind != bytecode.ValObject || v.Obj == nil || v.Obj.Type != bytecode.ObjArray {
+		return nil, fmt.Errorf("%s: value is not array", name)
+	}
+	return v.Obj.Items, nil
+}
+
+func str(name string, v bytecode.Value) (string, error) {
+	if v.Kind == bytecode.ValNull {
+		return "", &NullError{Func: name, What: "string"}
+	}
+	if v.Kind != bytecode.ValString {
+		return "", fmt.Errorf("%s: value is not string", name)
+	}
+	return v.S, nil
+}
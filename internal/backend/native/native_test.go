@@ -0,0 +1,38 @@
+package native_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend/native"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+func TestLookup(t *testing.T) {
+	i, f, ok := native.Lookup("split")
+	if !ok || f.Name != "split" {
+		t.Fatalf("split is not registered")
+	}
+	if g, ok := native.Get(i); !ok || g != f {
+		t.Fatalf("Get(%d) does not return split", i)
+	}
+	if _, _, ok := native.Lookup("nope"); ok {
+		t.Fatalf("unknown function found")
+	}
+	if _, ok := native.Get(-1); ok {
+		t.Fatalf("Get(-1) succeeded")
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("second registration of sqrt did not panic")
+		}
+	}()
+	float := types.Type{Kind: types.TypeFloat}
+	native.Register(&native.Func{
+		Name:       "sqrt",
+		ParamNames: []string{"x"},
+		Type:       types.FuncOf([]types.Type{float}, float),
+	})
+}
@@ -0,0 +1,39 @@
+package native
+
+import (
+	"fmt"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+func init() {
+	Register(&Func{
+		Name:       "random",
+		ParamNames: []string{},
+		Type:       types.FuncOf(nil, floatType),
+		Impl: func(env Env, _ []bytecode.Value) (bytecode.Value, error) {
+			return floatValue(env.Rand().Float64()), nil
+		},
+	})
+	Register(&Func{
+		Name:       "randomInt",
+		ParamNames: []string{"n"},
+		Type:       types.FuncOf([]types.Type{intType}, intType),
+		Impl: func(env Env, args []bytecode.Value) (bytecode.Value, error) {
+			if args[0].I <= 0 {
+				return bytecode.Value{}, fmt.Errorf("randomInt: bound %d must be positive", args[0].I)
+			}
+			return intValue(env.Rand().Int64N(args[0].I)), nil
+		},
+	})
+	Register(&Func{
+		Name:       "seed",
+		ParamNames: []string{"s"},
+		Type:       types.FuncOf([]types.Type{intType}, voidType),
+		Impl: func(env Env, args []bytecode.Value) (bytecode.Value, error) {
+			env.Seed(uint64(args[0].I))
+			return null(), nil
+		},
+	})
+}
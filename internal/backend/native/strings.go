@@ -0,0 +1,65 @@
+package native
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+func init() {
+	Register(&Func{
+		Name:       "substr",
+		ParamNames: []string{"s", "from", "to"},
+		Type:       types.FuncOf([]types.Type{stringType, intType, intType}, stringType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			s, err := str("substr", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			from, to := args[1].I, args[2].I
+			if from < 0 || from > to || to > int64(len(s)) {
+				return bytecode.Value{}, fmt.Errorf("substr: bounds [%d,%d) out of range [0,%d]", from, to, len(s))
+			}
+			return stringValue(s[from:to]), nil
+		},
+	})
+	Register(&Func{
+		Name:       "indexOf",
+		ParamNames: []string{"s", "sub"},
+		Type:       types.FuncOf([]types.Type{stringType, stringType}, intType),
+		Impl: func(_ Env, args []bytecode.Value) (bytecode.Value, error) {
+			s, err := str("indexOf", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			sub, err := str("indexOf", args[1])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			return intValue(int64(strings.Index(s, sub))), nil
+		},
+	})
+	Register(&Func{
+		Name:       "split",
+		ParamNames: []string{"s", "sep"},
+		Type:       types.FuncOf([]types.Type{stringType, stringType}, types.ArrayOf(stringType)),
+		Impl: func(env Env, args []bytecode.Value) (bytecode.Value, error) {
+			s, err := str("split", args[0])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			sep, err := str("split", args[1])
+			if err != nil {
+				return bytecode.Value{}, err
+			}
+			parts := strings.Split(s, sep)
+			items := make([]bytecode.Value, len(parts))
+			for i, p := range parts {
+				items[i] = stringValue(p)
+			}
+			return env.NewArray(items), nil
+		},
+	})
+}
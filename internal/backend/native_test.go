@@ -0,0 +1,90 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+func TestStandardLibrary(t *testing.T) {
+	mod, _ := compile(t, `
+function test() string {
+    int[] a = [5, 3, 9, 1]
+    int[] b = copy(a)
+    sort(a)
+    fill(b, 7)
+    string[] parts = split("x,yy,,z", ",")
+    string s = str(a[0]) + str(a[3]) + str(b[2]) + "|"
+    s += str(len(parts)) + parts[1] + "|"
+    s += str(indexOf("hello", "ll")) + str(indexOf("hello", "z")) + "|"
+    return s + str(sqrt(16.0)) + str(abs(-4)) + str(abs(-2.5)) + str(min(3, 8)) + max("a", "b") + str(floor(-1.5))
+}
+`, false)
+	if got := opCounts(t, mod, "test")[bytecode.OpCallNative]; got != 12 {
+		t.Fatalf("test has %d OpCallNative, want 12", got)
+	}
+	res, err := call(mod)
+	want := "197|4yy|2-1|442.53b-2"
+	if err != nil || res.S != want {
+		t.Fatalf("test() = %q, %v; want %q", res.S, err, want)
+	}
+}
+
+func TestNativeShadowedByProgram(t *testing.T) {
+	mod, _ := compile(t, `
+function sqrt(float x) float {
+    return x
+}
+
+function test() float {
+    return sqrt(9.0)
+}
+`, false)
+	if got := opCounts(t, mod, "test")[bytecode.OpCallNative]; got != 0 {
+		t.Fatalf("test has %d OpCallNative, want the program's sqrt", got)
+	}
+	res, err := call(mod)
+	if err != nil || res.F != 9 {
+		t.Fatalf("test() = %g, %v; want 9", res.F, err)
+	}
+}
+
+func TestNativeErrorIsCatchable(t *testing.T) {
+	res, err := run(t, `
+function test() string {
+    try {
+        return str(sqrt(-1.0))
+    } catch (string msg) {
+        return msg
+    }
+}
+`)
+	if err != nil || res.S != "sqrt: negative argument -1" {
+		t.Fatalf("test() = %q, %v; want the sqrt error", res.S, err)
+	}
+}
+
+func TestRandomSeed(t *testing.T) {
+	src := `
+function test() int {
+    seed(42)
+    int s = 0
+    for (int i = 0; i < 5; i++) {
+        int r = randomInt(10)
+        if (r < 0 || r >= 10) return -1
+        s = s * 10 + r
+    }
+    float f = random()
+    if (f < 0.0 || f >= 1.0) return -2
+    return s
+}
+`
+	first, err := run(t, src)
+	if err != nil || first.I < 0 {
+		t.Fatalf("test() = %d, %v", first.I, err)
+	}
+	second, err := run(t, src)
+	if err != nil || second.I != first.I {
+		t.Fatalf("seeded runs differ: %d and %d (%v)", first.I, second.I, err)
+	}
+}
@@ -0,0 +1,111 @@
+package backend_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/backend"
+)
+
+func TestNullReference(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		at     string // фрагмент, с которого начинается обращение
+		fn     string
+		inline bool
+	}{
+		{"field", `
+struct Node {
+    int value
+}
+
+function test() int {
+    Node n
+    return n.value
+}
+`, ".value", "test", false},
+		{"index", `
+function test() int {
+    int[] a
+    return a[2]
+}
+`, "[2]", "test", false},
+		{"call", `
+function test() int {
+    function(int) int f
+    return f(1)
+}
+`, "(1)", "test", false},
+		{"inlined", `
+function first(int[] a) int {
+    return a[0]
+}
+
+function test() int {
+    return first(null)
+}
+`, "[0]", "test", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, _ := compile(t, tt.src, tt.inline)
+			_, err := call(mod)
+			var ref *backend.NullReference
+			if !errors.As(err, &ref) {
+				t.Fatalf("err = %v, want NullReference", err)
+			}
+			if want := strings.Index(tt.src, tt.at); ref.Function != tt.fn || ref.Pos != want {
+				t.Fatalf("%v: want function %s at pos %d", err, tt.fn, want)
+			}
+		})
+	}
+}
+
+func TestPrimitiveZeroValues(t *testing.T) {
+	res, err := run(t, `
+function test() string {
+    int n
+    float f
+    bool b
+    string s
+    if (s == null) {
+        return str(n) + str(f) + str(b)
+    }
+    return "not null"
+}
+`)
+	if err != nil || res.S != "00false" {
+		t.Fatalf("test() = %q, %v; want %q", res.S, err, "00false")
+	}
+}
+
+func TestStringZeroValueIsNull(t *testing.T) {
+	res, err := run(t, `
+struct Person {
+    string name
+    int age
+}
+
+function test() bool {
+    string[] ss = new string[2]
+    Person p = new Person
+    return ss[1] == null && p.name == null
+}
+`)
+	if err != nil || !res.B {
+		t.Fatalf("test() = %v, %v; want true", res.B, err)
+	}
+
+	_, err = run(t, `
+function test() string {
+    string[] ss = new string[1]
+    return str(ss[0])
+}
+`)
+	var ref *backend.NullReference
+	if !errors.As(err, &ref) || ref.Message != "str: value is null" {
+		t.Fatalf("err = %v, want NullReference from str", err)
+	}
+}
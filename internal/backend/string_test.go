@@ -0,0 +1,51 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringOperations(t *testing.T) {
+	res, err := run(t, `
+function test() string {
+    string s = "hello"
+    string t = s + ", " + "world" + '!'
+    if ("abc" < "abd" && s[1] == 'e' && len(t) == 13) {
+        return substr(t, 7, 12) + str(parseInt("41") + 1) + str(parseFloat("2.5")) + str(true)
+    }
+    return "wrong"
+}
+`)
+	if err != nil || res.S != "world422.5true" {
+		t.Fatalf("test() = %q, %v; want %q", res.S, err, "world422.5true")
+	}
+}
+
+func TestStringRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"index out of range", `
+function test() char {
+    string s = "abc"
+    int i = 3
+    return s[i]
+}
+`, "out of range"},
+		{"bad number", `
+function test() int {
+    return parseInt("12a")
+}
+`, "parseInt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package backend_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStructs(t *testing.T) {
+	res, err := run(t, `
+struct Point { int x  int y }
+
+struct Node {
+    int value
+    Node next
+}
+
+function sum(Node n) int {
+    int s = 0
+    while (n != null) {
+        s = s + n.value
+        n = n.next
+    }
+    return s
+}
+
+function test() int {
+    Point[] ps = new Point[2]
+    ps[0] = new Point
+    ps[0].x = 3
+    ps[1] = ps[0]
+    ps[1].y = 4
+
+    Node head = new Node
+    head.value = 10
+    head.next = new Node
+    head.next.value = 20
+    return ps[0].x * 100 + ps[0].y * 1000 + sum(head)
+}
+`)
+	if err != nil || res.I != 4330 {
+		t.Fatalf("test() = %d, %v; want 4330", res.I, err)
+	}
+}
+
+func TestNullStructField(t *testing.T) {
+	_, err := run(t, `
+struct Node {
+    int value
+    Node next
+}
+
+function test() int {
+    Node n = new Node
+    return n.next.value
+}
+`)
+	if err == nil || !strings.Contains(err.Error(), "field access: struct is null") {
+		t.Fatalf("err = %v, want null field access", err)
+	}
+}
@@ -0,0 +1,119 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+// dense и sparse задают одно и то же отображение: плотные
+// значения 0..5 и разреженные, умноженные на 1000.
+const switchSrc = `
+function dense(int x) int {
+    switch (x) {
+    case 0, 6:
+        return 10
+    case 1:
+        return 11
+    case 2, 3:
+        return 12
+    case 5:
+        return 15
+    default:
+        return -1
+    }
+}
+
+function sparse(int x) int {
+    switch (x) {
+    case 0, 6000:
+        return 10
+    case 1000:
+        return 11
+    case 2000, 3000:
+        return 12
+    case 5000:
+        return 15
+    default:
+        return -1
+    }
+}
+
+function test() int {
+    int s = 0
+    for (int i = -1; i <= 7; i++) {
+        if (dense(i) != sparse(i * 1000)) {
+            return -100 - i
+        }
+        s = s * 100 + dense(i) + 1
+    }
+    return s
+}
+`
+
+func TestSwitchJumpTable(t *testing.T) {
+	mod, _ := compile(t, switchSrc, false)
+
+	dense := opCounts(t, mod, "dense")
+	if dense[bytecode.OpJumpTable] != 1 || dense[bytecode.OpEq] != 0 {
+		t.Fatalf("dense: %d OpJumpTable, %d OpEq; want a single table", dense[bytecode.OpJumpTable], dense[bytecode.OpEq])
+	}
+	sparse := opCounts(t, mod, "sparse")
+	if sparse[bytecode.OpJumpTable] != 0 || sparse[bytecode.OpEq] != 6 {
+		t.Fatalf("sparse: %d OpJumpTable, %d OpEq; want a chain of 6 comparisons", sparse[bytecode.OpJumpTable], sparse[bytecode.OpEq])
+	}
+
+	res, err := call(mod)
+	// i = -1..7: -1 10 11 12 12 -1 15 10 -1, каждое плюс 1
+	if err != nil || res.I != 1112131300161100 {
+		t.Fatalf("test() = %d, %v; want 1112131300161100", res.I, err)
+	}
+}
+
+func TestSwitchBreakAndContinue(t *testing.T) {
+	res, err := run(t, `
+function test() int {
+    int s = 0
+    for (int i = 0; i < 6; i++) {
+        switch (i) {
+        case 1:
+            continue
+        case 2, 4:
+            if (i == 4) {
+                break
+            }
+            s = s + 100
+        default:
+            s = s + 1
+        }
+        s = s + 10
+    }
+    return s
+}
+`)
+	// i=0: 11, i=1: 0, i=2: 110, i=3: 11, i=4: 10, i=5: 11
+	if err != nil || res.I != 153 {
+		t.Fatalf("test() = %d, %v; want 153", res.I, err)
+	}
+}
+
+func TestSwitchOnString(t *testing.T) {
+	res, err := run(t, `
+function code(string s) int {
+    switch (s) {
+    case "a", "b":
+        return 1
+    case "c":
+        return 2
+    }
+    return 0
+}
+
+function test() int {
+    return code("b") * 100 + code("c") * 10 + code("z")
+}
+`)
+	if err != nil || res.I != 120 {
+		t.Fatalf("test() = %d, %v; want 120", res.I, err)
+	}
+}
@@ -0,0 +1,76 @@
+package backend_test
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+const tailSrc = `
+function sum(int n, int acc) int {
+    if (n == 0) {
+        return acc
+    }
+    return sum(n - 1, acc + n)
+}
+
+function isEven(int n) bool {
+    if (n == 0) {
+        return true
+    }
+    return isOdd(n - 1)
+}
+
+function isOdd(int n) bool {
+    if (n == 0) {
+        return false
+    }
+    return isEven(n - 1)
+}
+
+function fac(int n) int {
+    if (n <= 1) {
+        return 1
+    }
+    return n * fac(n - 1)
+}
+
+function test() int {
+    int r = sum(1000000, 0)
+    if (isEven(1000001)) {
+        r = r + 1
+    }
+    return r + fac(5)
+}
+`
+
+func TestTailCallsEmitted(t *testing.T) {
+	mod, _ := compile(t, tailSrc, true)
+	for _, name := range []string{"sum", "isEven", "isOdd"} {
+		ops := opCounts(t, mod, name)
+		if ops[bytecode.OpTailCall] != 1 || ops[bytecode.OpCall] != 0 {
+			t.Errorf("%s: %d tail calls, %d calls; want 1 and 0", name, ops[bytecode.OpTailCall], ops[bytecode.OpCall])
+		}
+	}
+	// n * fac(n - 1) — не хвостовой вызов
+	if ops := opCounts(t, mod, "fac"); ops[bytecode.OpTailCall] != 0 || ops[bytecode.OpCall] != 1 {
+		t.Errorf("fac: %d tail calls, %d calls; want 0 and 1", ops[bytecode.OpTailCall], ops[bytecode.OpCall])
+	}
+}
+
+// Миллион вложенных вызовов без переиспользования фрейма не помещается
+// в ограниченный стек Go.
+func TestTailCallsDeepRecursion(t *testing.T) {
+	defer debug.SetMaxStack(debug.SetMaxStack(32 << 20))
+
+	mod, _ := compile(t, tailSrc, true)
+	res, err := call(mod)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// isEven(1000001) ложно
+	if want := int64(500000500000 + 120); res.I != want {
+		t.Fatalf("test() = %d, want %d", res.I, want)
+	}
+}
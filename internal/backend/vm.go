@@ -1,11 +1,19 @@
 package backend
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/rand/v2"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ChernykhITMO/compiler/internal/backend/jit"
+	"github.com/ChernykhITMO/compiler/internal/backend/native"
 	"github.com/ChernykhITMO/compiler/internal/bytecode"
 )
 
@@ -14,9 +22,16 @@ type rootSet struct {
 	stack  *[]bytecode.Value
 }
 type VM struct {
-	mod   *bytecode.Module
-	heap  bytecode.Heap
-	roots []rootSet
+	mod         *bytecode.Module
+	heap        bytecode.Heap
+	roots       []rootSet
+	globals     []bytecode.Value
+	initialized bool
+	checked     bool // переполнение int — ошибка, а не перенос
+	pcg         *rand.PCG
+	rng         *rand.Rand // над pcg, для random и randomInt
+	out         io.Writer
+	in          *bufio.Reader
 }
 
 func NewVM(mod *bytecode.Module, isActivatedJit bool) *VM {
@@ -26,7 +41,43 @@ func NewVM(mod *bytecode.Module, isActivatedJit bool) *VM {
 		}
 	}
 
-	return &VM{mod: mod}
+	globals := make([]bytecode.Value, len(mod.Globals))
+	for i := range globals {
+		globals[i] = bytecode.Value{Kind: bytecode.ValNull}
+	}
+
+	seed := uint64(time.Now().UnixNano())
+	pcg := rand.NewPCG(seed, seed)
+	return &VM{
+		mod:     mod,
+		globals: globals,
+		pcg:     pcg,
+		rng:     rand.New(pcg),
+		out:     os.Stdout,
+		in:      bufio.NewReader(os.Stdin),
+	}
+}
+
+// SetCheckedArithmetic включает проверку переполнения int64 в +, -, *, /, ^
+// и унарном минусе. По умолчанию результат переносится по модулю 2^64.
+func (vm *VM) SetCheckedArithmetic(on bool) {
+	vm.checked = on
+}
+
+// SetOutput направляет print, println и printf в w вместо os.Stdout.
+func (vm *VM) SetOutput(w io.Writer) {
+	vm.out = w
+}
+
+// SetInput задает, откуда читают readLine и readInt, вместо os.Stdin.
+func (vm *VM) SetInput(r io.Reader) {
+	vm.in = bufio.NewReader(r)
+}
+
+// SetRandomSeed задает начальное значение генератора random и randomInt,
+// как встроенная seed. По умолчанию генератор инициализируется временем.
+func (vm *VM) SetRandomSeed(seed uint64) {
+	vm.pcg.Seed(seed, seed)
 }
 
 func (vm *VM) Call(name string, args []bytecode.Value) (bytecode.Value, error) {
@@ -38,10 +89,20 @@ func (vm *VM) Call(name string, args []bytecode.Value) (bytecode.Value, error) {
 		return bytecode.Value{}, fmt.Errorf("function %q: expected %d args, got %d",
 			name, fn.ParamCount, len(args))
 	}
+
+	// глобальные переменные инициализируются один раз, до любого вызова
+	if !vm.initialized {
+		vm.initialized = true
+		if init, ok := vm.mod.Functions[bytecode.InitFunction]; ok {
+			if _, err := vm.runFunction(init, nil); err != nil {
+				return bytecode.Value{}, fmt.Errorf("global init: %w", err)
+			}
+		}
+	}
 	return vm.runFunction(fn, args)
 }
 
-func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (bytecode.Value, error) {
+func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (result bytecode.Value, err error) {
 	ch := &fn.Chunk
 
 	locals := make([]bytecode.Value, fn.NumLocals)
@@ -79,10 +140,20 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 		stack = append(stack, v)
 	}
 
+	// at — начало текущей инструкции: по нему ищется обработчик исключения.
+	// Ошибка из вложенного вызова всплывает на инструкции вызова.
+	at := 0
+	var thrown error
+	defer func() {
+		if r := recover(); r != nil {
+			err = uncheckedFault(fn, at, r)
+		}
+	}()
 	for {
 		if ip >= len(ch.Code) {
 			return bytecode.Value{Kind: bytecode.ValNull}, nil
 		}
+		at = ip
 		op := bytecode.OpCode(ch.Code[ip])
 		ip++
 
@@ -90,7 +161,8 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 		case bytecode.OpConst:
 			idx := readUint16()
 			if int(idx) >= len(ch.Constants) {
-				return bytecode.Value{}, fmt.Errorf("const index out of range: %d", idx)
+				thrown = fmt.Errorf("const index out of range: %d", idx)
+				goto unwind
 			}
 			push(ch.Constants[idx])
 
@@ -98,7 +170,8 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			slot := int(ch.Code[ip])
 			ip++
 			if slot < 0 || slot >= len(locals) {
-				return bytecode.Value{}, fmt.Errorf("load local: bad slot %d", slot)
+				thrown = fmt.Errorf("load local: bad slot %d", slot)
+				goto unwind
 			}
 			push(locals[slot])
 
@@ -106,17 +179,86 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			slot := int(ch.Code[ip])
 			ip++
 			if slot < 0 || slot >= len(locals) {
-				return bytecode.Value{}, fmt.Errorf("store local: bad slot %d", slot)
+				thrown = fmt.Errorf("store local: bad slot %d", slot)
+				goto unwind
 			}
 			v := pop()
 			locals[slot] = v
 
+		case bytecode.OpIncLocal:
+			slot := int(ch.Code[ip])
+			delta := int64(int8(ch.Code[ip+1]))
+			ip += 2
+			if slot < 0 || slot >= len(locals) {
+				thrown = fmt.Errorf("inc local: bad slot %d", slot)
+				goto unwind
+			}
+			if locals[slot].Kind != bytecode.ValInt {
+				thrown = fmt.Errorf("inc local: value is not int")
+				goto unwind
+			}
+			if vm.checked && bytecode.IntOverflows("+", locals[slot].I, delta) {
+				thrown = fmt.Errorf("integer overflow: %d + %d", locals[slot].I, delta)
+				goto unwind
+			}
+			locals[slot].I += delta
+
+		case bytecode.OpNewCell:
+			// значение остается на стеке, пока создается ячейка: GC его видит
+			if len(stack) == 0 {
+				panic("stack underflow")
+			}
+			cell := vm.newObject(bytecode.ObjUpvalue)
+			cell.Items = []bytecode.Value{pop()}
+			push(bytecode.Value{Kind: bytecode.ValObject, Obj: cell})
+
+		case bytecode.OpLoadCell:
+			slot := int(ch.Code[ip])
+			ip++
+			cell, err := cellAt(locals, slot)
+			if err != nil {
+				thrown = err
+				goto unwind
+			}
+			push(cell.Items[0])
+
+		case bytecode.OpStoreCell:
+			slot := int(ch.Code[ip])
+			ip++
+			cell, err := cellAt(locals, slot)
+			if err != nil {
+				thrown = err
+				goto unwind
+			}
+			cell.Items[0] = pop()
+
+		case bytecode.OpLoadGlobal:
+			slot := int(readUint16())
+			if slot >= len(vm.globals) {
+				thrown = fmt.Errorf("load global: bad slot %d", slot)
+				goto unwind
+			}
+			push(vm.globals[slot])
+
+		case bytecode.OpStoreGlobal:
+			slot := int(readUint16())
+			if slot >= len(vm.globals) {
+				thrown = fmt.Errorf("store global: bad slot %d", slot)
+				goto unwind
+			}
+			vm.globals[slot] = pop()
+
 		case bytecode.OpAdd:
 			b := pop()
 			a := pop()
+			if s, ok := bytecode.Concat(a, b); ok {
+				push(s)
+				break
+			}
 			res, err := vm.binaryNumberOp("+", a, b)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
 			}
 			push(res)
 
@@ -125,7 +267,8 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			a := pop()
 			res, err := vm.binaryNumberOp("-", a, b)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
 			}
 			push(res)
 
@@ -134,7 +277,8 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			a := pop()
 			res, err := vm.binaryNumberOp("*", a, b)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
 			}
 			push(res)
 
@@ -143,7 +287,8 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			a := pop()
 			res, err := vm.binaryNumberOp("/", a, b)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
 			}
 			push(res)
 
@@ -152,7 +297,8 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			a := pop()
 			res, err := vm.binaryNumberOp("%", a, b)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
 			}
 			push(res)
 
@@ -161,7 +307,8 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			a := pop()
 			res, err := vm.binaryNumberOp("^", a, b)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
 			}
 			push(res)
 
@@ -180,18 +327,24 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			a := pop()
 			res, err := vm.compareNumbers(op, a, b)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
 			}
 			push(boolValue(res))
 
 		case bytecode.OpNeg:
 			v := pop()
 			if v.Kind != bytecode.ValFloat && v.Kind != bytecode.ValInt {
-				return bytecode.Value{}, fmt.Errorf("unary - on non-number")
+				thrown = fmt.Errorf("unary - on non-number")
+				goto unwind
 			}
 			if v.Kind == bytecode.ValFloat {
 				v.F = -v.F
 			} else {
+				if vm.checked && v.I == math.MinInt64 {
+					thrown = fmt.Errorf("integer overflow: -(%d)", v.I)
+					goto unwind
+				}
 				v.I = -v.I
 			}
 			push(v)
@@ -200,19 +353,67 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			v := pop()
 			push(boolValue(!vm.isTruthy(v)))
 
+		case bytecode.OpBitAnd, bytecode.OpBitOr, bytecode.OpBitXor, bytecode.OpShl, bytecode.OpShr:
+			b := pop()
+			a := pop()
+			if a.Kind != bytecode.ValInt || b.Kind != bytecode.ValInt {
+				thrown = fmt.Errorf("bitwise %s: operands must be int", bitwiseOps[op])
+				goto unwind
+			}
+			res, err := bytecode.IntOp(bitwiseOps[op], a.I, b.I)
+			if err != nil {
+				thrown = err
+				goto unwind
+			}
+			push(bytecode.Value{Kind: bytecode.ValInt, I: res})
+
+		case bytecode.OpToInt, bytecode.OpToFloat, bytecode.OpToChar:
+			v, err := bytecode.Convert(pop(), conversions[op])
+			if err != nil {
+				thrown = fmt.Errorf("conversion: %w", err)
+				goto unwind
+			}
+			push(v)
+
+		case bytecode.OpBitNot:
+			v := pop()
+			if v.Kind != bytecode.ValInt {
+				thrown = fmt.Errorf("bitwise ~: operand must be int")
+				goto unwind
+			}
+			v.I = ^v.I
+			push(v)
+
 		case bytecode.OpJump:
 			target := int(readUint16())
 			if target < 0 || target > len(ch.Code) {
-				return bytecode.Value{}, fmt.Errorf("jump: bad target %d", target)
+				thrown = fmt.Errorf("jump: bad target %d", target)
+				goto unwind
 			}
 			ip = target
 
+		case bytecode.OpJumpTable:
+			idx := int(readUint16())
+			if idx >= len(ch.JumpTables) {
+				thrown = fmt.Errorf("jump table: bad index %d", idx)
+				goto unwind
+			}
+			t := &ch.JumpTables[idx]
+			v := pop()
+			ip = t.Default
+			if v.Kind == bytecode.ValInt {
+				if off := v.I - t.Min; off >= 0 && off < int64(len(t.Targets)) {
+					ip = t.Targets[off]
+				}
+			}
+
 		case bytecode.OpJumpIfFalse:
 			target := int(readUint16())
 			top := stack[len(stack)-1]
 			if !vm.isTruthy(top) {
 				if target < 0 || target > len(ch.Code) {
-					return bytecode.Value{}, fmt.Errorf("jump-if-false: bad target %d", target)
+					thrown = fmt.Errorf("jump-if-false: bad target %d", target)
+					goto unwind
 				}
 				ip = target
 			}
@@ -220,25 +421,41 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 		case bytecode.OpPop:
 			_ = pop()
 
+		case bytecode.OpDup:
+			if len(stack) < 1 {
+				panic("stack underflow")
+			}
+			push(stack[len(stack)-1])
+
+		case bytecode.OpDup2:
+			if len(stack) < 2 {
+				panic("stack underflow")
+			}
+			stack = append(stack, stack[len(stack)-2], stack[len(stack)-1])
+
 		case bytecode.OpCall:
 			idx := readUint16()
 			if int(idx) >= len(ch.Constants) {
-				return bytecode.Value{}, fmt.Errorf("call: const index out of range %d", idx)
+				thrown = fmt.Errorf("call: const index out of range %d", idx)
+				goto unwind
 			}
 			constVal := ch.Constants[idx]
 			if constVal.Kind != bytecode.ValString {
-				return bytecode.Value{}, fmt.Errorf("call: const is not string (function name)")
+				thrown = fmt.Errorf("call: const is not string (function name)")
+				goto unwind
 			}
 			calleeName := constVal.S
 			callee, ok := vm.mod.Functions[calleeName]
 			if !ok {
-				return bytecode.Value{}, fmt.Errorf("unknown function %q", calleeName)
+				thrown = fmt.Errorf("unknown function %q", calleeName)
+				goto unwind
 			}
 
 			n := callee.ParamCount
 			if len(stack) < n {
-				return bytecode.Value{}, fmt.Errorf("call %q: stack has %d values, want %d args",
+				thrown = fmt.Errorf("call %q: stack has %d values, want %d args",
 					calleeName, len(stack), n)
+				goto unwind
 			}
 
 			argsVals := make([]bytecode.Value, n)
@@ -247,12 +464,148 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 
 			ret, err := vm.runFunction(callee, argsVals)
 			if err != nil {
-				return bytecode.Value{}, err
+				thrown = err
+				goto unwind
+			}
+			push(ret)
+
+		case bytecode.OpTailCall:
+			idx := readUint16()
+			if int(idx) >= len(ch.Constants) {
+				thrown = fmt.Errorf("tail call: const index out of range %d", idx)
+				goto unwind
+			}
+			constVal := ch.Constants[idx]
+			if constVal.Kind != bytecode.ValString {
+				thrown = fmt.Errorf("tail call: const is not string (function name)")
+				goto unwind
+			}
+			calleeName := constVal.S
+			callee, ok := vm.mod.Functions[calleeName]
+			if !ok {
+				thrown = fmt.Errorf("unknown function %q", calleeName)
+				goto unwind
+			}
+
+			n := callee.ParamCount
+			if len(stack) < n {
+				thrown = fmt.Errorf("tail call %q: stack has %d values, want %d args",
+					calleeName, len(stack), n)
+				goto unwind
+			}
+
+			argsVals := make([]bytecode.Value, n)
+			copy(argsVals, stack[len(stack)-n:])
+
+			// вместо нового runFunction переиспользуем текущий фрейм:
+			// locals и stack остаются теми же переменными, на них смотрит rootSet
+			fn = callee
+			ch = &fn.Chunk
+			if cap(locals) >= fn.NumLocals {
+				locals = locals[:fn.NumLocals]
+				clear(locals)
+			} else {
+				locals = make([]bytecode.Value, fn.NumLocals)
+			}
+			copy(locals, argsVals)
+			stack = stack[:0]
+			ip = 0
+		case bytecode.OpClosure:
+			idx := readUint16()
+			if int(idx) >= len(ch.Constants) || ch.Constants[idx].Kind != bytecode.ValString {
+				thrown = fmt.Errorf("closure: bad function name constant %d", idx)
+				goto unwind
+			}
+			callee, ok := vm.mod.Functions[ch.Constants[idx].S]
+			if !ok {
+				thrown = fmt.Errorf("unknown function %q", ch.Constants[idx].S)
+				goto unwind
+			}
+			n := callee.NumUpvalues
+			if len(stack) < n {
+				thrown = fmt.Errorf("closure %q: stack has %d values, want %d cells",
+					callee.Name, len(stack), n)
+				goto unwind
+			}
+
+			// ячейки остаются на стеке, пока создается объект: GC их видит
+			obj := vm.newObject(bytecode.ObjClosure)
+			obj.Fn = callee
+			obj.Items = make([]bytecode.Value, n)
+			copy(obj.Items, stack[len(stack)-n:])
+			stack = stack[:len(stack)-n]
+			push(bytecode.Value{Kind: bytecode.ValObject, Obj: obj})
+
+		case bytecode.OpCallIndirect:
+			n := int(ch.Code[ip])
+			ip++
+			if len(stack) < n+1 {
+				thrown = fmt.Errorf("indirect call: stack has %d values, want %d", len(stack), n+1)
+				goto unwind
+			}
+			calleeVal := stack[len(stack)-n-1]
+			if calleeVal.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "indirect call: function is null")
+				goto unwind
+			}
+			if calleeVal.Kind != bytecode.ValObject || calleeVal.Obj == nil || calleeVal.Obj.Type != bytecode.ObjClosure {
+				thrown = fmt.Errorf("indirect call: value is not a function")
+				goto unwind
+			}
+			callee := calleeVal.Obj.Fn
+			if callee.ParamCount != n {
+				thrown = fmt.Errorf("indirect call %q: expected %d args, got %d",
+					callee.Name, callee.ParamCount, n)
+				goto unwind
+			}
+
+			// аргументы, затем ячейки захвата — в том порядке, в каком их ждут локалы
+			argsVals := make([]bytecode.Value, n+len(calleeVal.Obj.Items))
+			copy(argsVals, stack[len(stack)-n:])
+			copy(argsVals[n:], calleeVal.Obj.Items)
+			stack = stack[:len(stack)-n-1]
+
+			ret, err := vm.runFunction(callee, argsVals)
+			if err != nil {
+				thrown = err
+				goto unwind
 			}
 			push(ret)
+
+		case bytecode.OpCallNative:
+			f, ok := native.Get(int(readUint16()))
+			n := int(ch.Code[ip])
+			ip++
+			if !ok {
+				thrown = fmt.Errorf("call native: bad function index")
+				goto unwind
+			}
+			if len(stack) < n {
+				thrown = fmt.Errorf("%s: stack has %d values, want %d args", f.Name, len(stack), n)
+				goto unwind
+			}
+			// аргументы остаются на стеке на время вызова: GC их видит
+			ret, err := f.Impl(vmEnv{vm}, stack[len(stack)-n:])
+			if err != nil {
+				thrown = err
+				if errors.As(err, new(*native.NullError)) {
+					thrown = nullReference(fn, at, err.Error())
+				}
+				goto unwind
+			}
+			stack = stack[:len(stack)-n]
+			push(ret)
+
 		case bytecode.OpPrint:
 			v := pop()
-			fmt.Print(formatValue(v) + " ")
+			if _, err := io.WriteString(vm.out, bytecode.FormatValue(v)+" "); err != nil {
+				thrown = fmt.Errorf("print: %w", err)
+				goto unwind
+			}
+
+		case bytecode.OpThrow:
+			thrown = &Exception{Value: pop()}
+			goto unwind
 
 		case bytecode.OpReturn:
 			if len(stack) == 0 {
@@ -261,36 +614,201 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			return stack[len(stack)-1], nil
 
 		case bytecode.OpArrayNew:
-			lenVal := pop()
-			if lenVal.Kind != bytecode.ValInt {
-				return bytecode.Value{}, fmt.Errorf("array new: length must be int")
+			// на стеке: значение элементов, затем размеры всех заданных измерений
+			dims := int(readUint16())
+			if dims == 0 || len(stack) < dims+1 {
+				thrown = fmt.Errorf("array new: stack has %d values, want %d", len(stack), dims+1)
+				goto unwind
 			}
-			if lenVal.I < 0 {
-				return bytecode.Value{}, fmt.Errorf("array new: length must be >= 0")
+			lengths := make([]int, dims)
+			for i, lenVal := range stack[len(stack)-dims:] {
+				if lenVal.Kind != bytecode.ValInt {
+					thrown = fmt.Errorf("array new: length must be int")
+					goto unwind
+				}
+				if lenVal.I < 0 {
+					thrown = fmt.Errorf("array new: length must be >= 0")
+					goto unwind
+				}
+				lengths[i] = int(lenVal.I)
+			}
+			fill := stack[len(stack)-dims-1]
+			stack = stack[:len(stack)-dims-1]
+
+			obj := vm.newObject(bytecode.ObjArray)
+			obj.Items = make([]bytecode.Value, lengths[0])
+
+			// внешний массив сначала кладется на стек: пока создаются
+			// вложенные, GC видит уже построенную часть
+			push(bytecode.Value{
+				Kind: bytecode.ValObject,
+				Obj:  obj,
+			})
+			vm.fillArray(obj, lengths[1:], fill)
+
+		case bytecode.OpArrayLiteral:
+			n := int(readUint16())
+			if len(stack) < n {
+				thrown = fmt.Errorf("array literal: stack has %d values, want %d", len(stack), n)
+				goto unwind
 			}
-			n := int(lenVal.I)
 
+			// элементы остаются на стеке, пока объект создается: GC их видит
 			obj := vm.newObject(bytecode.ObjArray)
 			obj.Items = make([]bytecode.Value, n)
+			copy(obj.Items, stack[len(stack)-n:])
+			stack = stack[:len(stack)-n]
 
 			push(bytecode.Value{
 				Kind: bytecode.ValObject,
 				Obj:  obj,
 			})
 
+		case bytecode.OpLen:
+			v := pop()
+			switch {
+			case v.Kind == bytecode.ValNull:
+				thrown = nullReference(fn, at, "len: value is null")
+				goto unwind
+			case v.Kind == bytecode.ValString:
+				push(bytecode.Value{Kind: bytecode.ValInt, I: int64(len(v.S))})
+			case v.Kind == bytecode.ValObject && v.Obj != nil && (v.Obj.Type == bytecode.ObjArray || v.Obj.Type == bytecode.ObjList):
+				push(bytecode.Value{Kind: bytecode.ValInt, I: int64(len(v.Obj.Items))})
+			case v.Kind == bytecode.ValObject && v.Obj != nil && v.Obj.Type == bytecode.ObjMap:
+				push(bytecode.Value{Kind: bytecode.ValInt, I: int64(len(v.Obj.Map))})
+			default:
+				thrown = fmt.Errorf("len: value is not array, string or collection")
+				goto unwind
+			}
+
+		case bytecode.OpStructNew:
+			idx := readUint16()
+			if int(idx) >= len(ch.Constants) {
+				thrown = fmt.Errorf("struct new: const index out of range %d", idx)
+				goto unwind
+			}
+			info, ok := vm.mod.Structs[ch.Constants[idx].S]
+			if !ok {
+				thrown = fmt.Errorf("struct new: unknown struct %q", ch.Constants[idx].S)
+				goto unwind
+			}
+
+			obj := vm.newObject(bytecode.ObjStruct)
+			obj.Struct = info
+			obj.Items = make([]bytecode.Value, len(info.FieldTypes))
+			for i, t := range info.FieldTypes {
+				obj.Items[i] = bytecode.ZeroValue(t)
+			}
+			push(bytecode.Value{Kind: bytecode.ValObject, Obj: obj})
+
+		case bytecode.OpGetField:
+			field := int(ch.Code[ip])
+			ip++
+			obj := pop()
+			if obj.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "field access: struct is null")
+				goto unwind
+			}
+			if err := checkStruct(obj, field); err != nil {
+				thrown = err
+				goto unwind
+			}
+			push(obj.Obj.Items[field])
+
+		case bytecode.OpSetField:
+			field := int(ch.Code[ip])
+			ip++
+			val := pop()
+			obj := pop()
+			if obj.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "field access: struct is null")
+				goto unwind
+			}
+			if err := checkStruct(obj, field); err != nil {
+				thrown = err
+				goto unwind
+			}
+			obj.Obj.Items[field] = val
+
+		case bytecode.OpStr:
+			v := pop()
+			if v.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "str: value is null")
+				goto unwind
+			}
+			if v.Kind == bytecode.ValObject {
+				thrown = fmt.Errorf("str: cannot convert %s", bytecode.FormatValue(v))
+				goto unwind
+			}
+			push(bytecode.Value{Kind: bytecode.ValString, S: bytecode.FormatValue(v)})
+
+		case bytecode.OpParseInt:
+			v := pop()
+			if v.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "parseInt: string is null")
+				goto unwind
+			}
+			if v.Kind != bytecode.ValString {
+				thrown = fmt.Errorf("parseInt: value is not string")
+				goto unwind
+			}
+			i, err := strconv.ParseInt(strings.TrimSpace(v.S), 10, 64)
+			if err != nil {
+				thrown = fmt.Errorf("parseInt: invalid integer %q", v.S)
+				goto unwind
+			}
+			push(bytecode.Value{Kind: bytecode.ValInt, I: i})
+
+		case bytecode.OpParseFloat:
+			v := pop()
+			if v.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "parseFloat: string is null")
+				goto unwind
+			}
+			if v.Kind != bytecode.ValString {
+				thrown = fmt.Errorf("parseFloat: value is not string")
+				goto unwind
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(v.S), 64)
+			if err != nil {
+				thrown = fmt.Errorf("parseFloat: invalid number %q", v.S)
+				goto unwind
+			}
+			push(bytecode.Value{Kind: bytecode.ValFloat, F: f})
+
 		case bytecode.OpArrayGet:
 			idxVal := pop()
 			arrVal := pop()
 
+			if arrVal.Kind == bytecode.ValString {
+				if idxVal.Kind != bytecode.ValInt {
+					thrown = fmt.Errorf("string index: index must be int")
+					goto unwind
+				}
+				idx := int(idxVal.I)
+				if idx < 0 || idx >= len(arrVal.S) {
+					thrown = fmt.Errorf("string index: index %d out of range [0,%d)", idx, len(arrVal.S))
+					goto unwind
+				}
+				push(bytecode.Value{Kind: bytecode.ValChar, C: arrVal.S[idx]})
+				break
+			}
+			if arrVal.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "array get: value is null")
+				goto unwind
+			}
 			if arrVal.Kind != bytecode.ValObject || arrVal.Obj == nil || arrVal.Obj.Type != bytecode.ObjArray {
-				return bytecode.Value{}, fmt.Errorf("array get: value is not array")
+				thrown = fmt.Errorf("array get: value is not array")
+				goto unwind
 			}
 			if idxVal.Kind != bytecode.ValInt {
-				return bytecode.Value{}, fmt.Errorf("array get: index must be int")
+				thrown = fmt.Errorf("array get: index must be int")
+				goto unwind
 			}
 			idx := int(idxVal.I)
 			if idx < 0 || idx >= len(arrVal.Obj.Items) {
-				return bytecode.Value{}, fmt.Errorf("array get: index %d out of range [0,%d)", idx, len(arrVal.Obj.Items))
+				thrown = fmt.Errorf("array get: index %d out of range [0,%d)", idx, len(arrVal.Obj.Items))
+				goto unwind
 			}
 
 			push(arrVal.Obj.Items[idx])
@@ -300,52 +818,151 @@ func (vm *VM) runFunction(fn *bytecode.FunctionInfo, args []bytecode.Value) (byt
 			idxVal := pop()
 			arrVal := pop()
 
+			if arrVal.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "array set: array is null")
+				goto unwind
+			}
 			if arrVal.Kind != bytecode.ValObject || arrVal.Obj == nil || arrVal.Obj.Type != bytecode.ObjArray {
-				return bytecode.Value{}, fmt.Errorf("array set: value is not array")
+				thrown = fmt.Errorf("array set: value is not array")
+				goto unwind
 			}
 			if idxVal.Kind != bytecode.ValInt {
-				return bytecode.Value{}, fmt.Errorf("array set: index must be int")
+				thrown = fmt.Errorf("array set: index must be int")
+				goto unwind
 			}
 			idx := int(idxVal.I)
 			if idx < 0 || idx >= len(arrVal.Obj.Items) {
-				return bytecode.Value{}, fmt.Errorf("array set: index %d out of range [0,%d)", idx, len(arrVal.Obj.Items))
+				thrown = fmt.Errorf("array set: index %d out of range [0,%d)", idx, len(arrVal.Obj.Items))
+				goto unwind
 			}
 
 			arrVal.Obj.Items[idx] = val
 
+		// границы доказал оптимизатор, длина не сравнивается; ошибка анализа
+		// дает панику Go, которую uncheckedFault превращает в ошибку VM
+		case bytecode.OpArrayGetUnchecked:
+			idxVal := pop()
+			arrVal := pop()
+			if arrVal.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "array get: value is null")
+				goto unwind
+			}
+			if arrVal.Kind != bytecode.ValObject || arrVal.Obj == nil || arrVal.Obj.Type != bytecode.ObjArray {
+				thrown = fmt.Errorf("array get: value is not array")
+				goto unwind
+			}
+			push(arrVal.Obj.Items[idxVal.I])
+
+		case bytecode.OpArraySetUnchecked:
+			val := pop()
+			idxVal := pop()
+			arrVal := pop()
+			if arrVal.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "array set: array is null")
+				goto unwind
+			}
+			if arrVal.Kind != bytecode.ValObject || arrVal.Obj == nil || arrVal.Obj.Type != bytecode.ObjArray {
+				thrown = fmt.Errorf("array set: value is not array")
+				goto unwind
+			}
+			arrVal.Obj.Items[idxVal.I] = val
+
 		case bytecode.OpArraySwapJit:
 			idxVal := pop()
 			arrVal := pop()
 
+			if arrVal.Kind == bytecode.ValNull {
+				thrown = nullReference(fn, at, "array swap: array is null")
+				goto unwind
+			}
 			if arrVal.Kind != bytecode.ValObject || arrVal.Obj == nil || arrVal.Obj.Type != bytecode.ObjArray {
-				return bytecode.Value{}, fmt.Errorf("array swap: value is not array")
+				thrown = fmt.Errorf("array swap: value is not array")
+				goto unwind
 			}
 			if idxVal.Kind != bytecode.ValInt {
-				return bytecode.Value{}, fmt.Errorf("array swap: index must be int")
+				thrown = fmt.Errorf("array swap: index must be int")
+				goto unwind
 			}
 
 			j := int(idxVal.I)
 			items := arrVal.Obj.Items
 
 			if j < 0 || j+1 >= len(items) {
-				return bytecode.Value{}, fmt.Errorf("array swap: index %d out of range", j)
+				thrown = fmt.Errorf("array swap: index %d out of range", j)
+				goto unwind
 			}
 
 			a := items[j]
 			b := items[j+1]
 
-			if a.Kind != bytecode.ValInt || b.Kind != bytecode.ValInt {
-				return bytecode.Value{}, fmt.Errorf("array swap: non-int elements")
+			// сравнение как у OpGt: элементы любого упорядоченного типа
+			greater, err := vm.compareNumbers(bytecode.OpGt, a, b)
+			if err != nil {
+				thrown = fmt.Errorf("array swap: %w", err)
+				goto unwind
 			}
-
-			if a.I > b.I {
+			if greater {
 				items[j] = b
 				items[j+1] = a
 			}
 
 		default:
-			return bytecode.Value{}, fmt.Errorf("unknown opcode %d", op)
+			thrown = fmt.Errorf("unknown opcode %d", op)
+			goto unwind
+		}
+		continue
+
+	unwind:
+		// ошибка VM или throw: ищем ветку catch в этом фрейме, иначе
+		// ошибка уходит в вызывающий фрейм
+		exc := exceptionValue(thrown)
+		h, ok := findHandler(fn, at, exc)
+		if !ok {
+			return bytecode.Value{}, thrown
 		}
+		stack = append(stack[:h.Depth], exc)
+		ip = h.Target
+	}
+}
+
+// cellAt — ячейка захваченной переменной в локале slot.
+func cellAt(locals []bytecode.Value, slot int) (*bytecode.Object, error) {
+	if slot < 0 || slot >= len(locals) {
+		return nil, fmt.Errorf("cell: bad slot %d", slot)
+	}
+	v := locals[slot]
+	if v.Kind != bytecode.ValObject || v.Obj == nil || v.Obj.Type != bytecode.ObjUpvalue {
+		return nil, fmt.Errorf("cell: local %d is not a cell", slot)
+	}
+	return v.Obj, nil
+}
+
+// checkStruct: v — структура с полем номер field.
+func checkStruct(v bytecode.Value, field int) error {
+	if v.Kind != bytecode.ValObject || v.Obj == nil || v.Obj.Type != bytecode.ObjStruct {
+		return fmt.Errorf("field access: value is not struct")
+	}
+	if field >= len(v.Obj.Items) {
+		return fmt.Errorf("field access: field %d out of range [0,%d)", field, len(v.Obj.Items))
+	}
+	return nil
+}
+
+// fillArray заполняет obj вложенными массивами размеров lengths,
+// а самые внутренние — значением fill.
+func (vm *VM) fillArray(obj *bytecode.Object, lengths []int, fill bytecode.Value) {
+	if len(lengths) == 0 {
+		for i := range obj.Items {
+			obj.Items[i] = fill
+		}
+		return
+	}
+
+	for i := range obj.Items {
+		inner := vm.newObject(bytecode.ObjArray)
+		inner.Items = make([]bytecode.Value, lengths[0])
+		obj.Items[i] = bytecode.Value{Kind: bytecode.ValObject, Obj: inner}
+		vm.fillArray(inner, lengths[1:], fill)
 	}
 }
 
@@ -375,6 +992,8 @@ func (vm *VM) equal(a, b bytecode.Value) bool {
 		return a.S == b.S
 	case bytecode.ValChar:
 		return a.C == b.C
+	case bytecode.ValObject:
+		return a.Obj == b.Obj
 	default:
 		return false
 	}
@@ -384,6 +1003,20 @@ func boolValue(b bool) bytecode.Value {
 	return bytecode.Value{Kind: bytecode.ValBool, B: b}
 }
 
+var bitwiseOps = map[bytecode.OpCode]string{
+	bytecode.OpBitAnd: "&",
+	bytecode.OpBitOr:  "|",
+	bytecode.OpBitXor: "xor",
+	bytecode.OpShl:    "<<",
+	bytecode.OpShr:    ">>",
+}
+
+var conversions = map[bytecode.OpCode]bytecode.TypeKind{
+	bytecode.OpToInt:   bytecode.TypeInt,
+	bytecode.OpToFloat: bytecode.TypeFloat,
+	bytecode.OpToChar:  bytecode.TypeChar,
+}
+
 func (vm *VM) binaryNumberOp(op string, a, b bytecode.Value) (bytecode.Value, error) {
 	if a.Kind != b.Kind {
 		return bytecode.Value{}, fmt.Errorf("numeric op %s: mixed types %v and %v", op, a.Kind, b.Kind)
@@ -391,14 +1024,17 @@ func (vm *VM) binaryNumberOp(op string, a, b bytecode.Value) (bytecode.Value, er
 
 	switch a.Kind {
 	case bytecode.ValInt:
-		v, err := intOp(op, a.I, b.I)
+		if vm.checked && bytecode.IntOverflows(op, a.I, b.I) {
+			return bytecode.Value{}, fmt.Errorf("integer overflow: %d %s %d", a.I, op, b.I)
+		}
+		v, err := bytecode.IntOp(op, a.I, b.I)
 		if err != nil {
 			return bytecode.Value{}, err
 		}
 		return bytecode.Value{Kind: bytecode.ValInt, I: v}, nil
 
 	case bytecode.ValFloat:
-		v, err := floatOp(op, a.F, b.F)
+		v, err := bytecode.FloatOp(op, a.F, b.F)
 		if err != nil {
 			return bytecode.Value{}, err
 		}
@@ -409,50 +1045,6 @@ func (vm *VM) binaryNumberOp(op string, a, b bytecode.Value) (bytecode.Value, er
 	}
 }
 
-func intOp(op string, a, b int64) (int64, error) {
-	switch op {
-	case "+":
-		return a + b, nil
-	case "-":
-		return a - b, nil
-	case "*":
-		return a * b, nil
-	case "/":
-		if b == 0 {
-			return 0, fmt.Errorf("division by zero")
-		}
-		return a / b, nil
-	case "%":
-		if b == 0 {
-			return 0, fmt.Errorf("modulo by zero")
-		}
-		return a % b, nil
-	case "^":
-		return int64(math.Pow(float64(a), float64(b))), nil
-	default:
-		return 0, fmt.Errorf("unknown int op %q", op)
-	}
-}
-
-func floatOp(op string, a, b float64) (float64, error) {
-	switch op {
-	case "+":
-		return a + b, nil
-	case "-":
-		return a - b, nil
-	case "*":
-		return a * b, nil
-	case "/":
-		return a / b, nil
-	case "%":
-		return math.Mod(a, b), nil
-	case "^":
-		return math.Pow(a, b), nil
-	default:
-		return 0, fmt.Errorf("unknown float op %q", op)
-	}
-}
-
 func (vm *VM) compareNumbers(op bytecode.OpCode, a, b bytecode.Value) (bool, error) {
 	if a.Kind != b.Kind {
 		return false, fmt.Errorf("compare: mixed types %v and %v", a.Kind, b.Kind)
@@ -465,8 +1057,14 @@ func (vm *VM) compareNumbers(op bytecode.OpCode, a, b bytecode.Value) (bool, err
 	case bytecode.ValFloat:
 		return compareFloat(op, a.F, b.F)
 
+	case bytecode.ValChar:
+		return compareInt(op, int64(a.C), int64(b.C))
+
+	case bytecode.ValString:
+		return compareInt(op, int64(strings.Compare(a.S, b.S)), 0)
+
 	default:
-		return false, fmt.Errorf("compare: not a number")
+		return false, fmt.Errorf("compare: values are not ordered")
 	}
 }
 
@@ -499,28 +1097,3 @@ func compareFloat(op bytecode.OpCode, a, b float64) (bool, error) {
 		return false, fmt.Errorf("unknown compare op %d", op)
 	}
 }
-
-func formatValue(v bytecode.Value) string {
-	switch v.Kind {
-	case bytecode.ValInt:
-		return strconv.FormatInt(v.I, 10)
-
-	case bytecode.ValFloat:
-		return strconv.FormatFloat(v.F, 'g', -1, 64)
-
-	case bytecode.ValBool:
-		return strconv.FormatBool(v.B)
-
-	case bytecode.ValChar:
-		return string(v.C) // или string(v.C), если C byte
-
-	case bytecode.ValString:
-		return v.S
-
-	case bytecode.ValNull:
-		return "null"
-
-	default:
-		return "<invalid>"
-	}
-}
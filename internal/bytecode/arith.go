@@ -0,0 +1,199 @@
+package bytecode
+
+import (
+	"fmt"
+	"math"
+)
+
+// IntOp и FloatOp — арифметика VM. Вынесены сюда, чтобы свертка констант
+// в оптимизаторе давала ровно тот же результат, что и исполнение.
+
+func IntOp(op string, a, b int64) (int64, error) {
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return 0, fmt.Errorf("modulo by zero")
+		}
+		return a % b, nil
+	case "^":
+		if b < 0 {
+			return 0, fmt.Errorf("negative exponent %d", b)
+		}
+		return intPow(a, b), nil
+	case "&":
+		return a & b, nil
+	case "|":
+		return a | b, nil
+	case "xor":
+		return a ^ b, nil
+	case "<<", ">>":
+		if b < 0 {
+			return 0, fmt.Errorf("negative shift count %d", b)
+		}
+		if op == "<<" {
+			return a << uint64(b), nil
+		}
+		return a >> uint64(b), nil
+	default:
+		return 0, fmt.Errorf("unknown int op %q", op)
+	}
+}
+
+// intPow — возведение в степень двоичным алгоритмом, с переполнением
+// по модулю 2^64, как у + и *.
+func intPow(a, b int64) int64 {
+	res := int64(1)
+	for b > 0 {
+		if b&1 == 1 {
+			res *= a
+		}
+		a *= a
+		b >>= 1
+	}
+	return res
+}
+
+// IntOverflows сообщает, что точный результат a op b не помещается в int64.
+// Для сдвигов и битовых операций переполнения нет.
+func IntOverflows(op string, a, b int64) bool {
+	switch op {
+	case "+":
+		res := a + b
+		return (a > 0 && b > 0 && res < 0) || (a < 0 && b < 0 && res >= 0)
+	case "-":
+		res := a - b
+		return (a >= 0 && b < 0 && res < 0) || (a < 0 && b > 0 && res >= 0)
+	case "*":
+		return mulOverflows(a, b)
+	case "/":
+		return a == math.MinInt64 && b == -1
+	case "^":
+		return powOverflows(a, b)
+	default:
+		return false
+	}
+}
+
+func mulOverflows(a, b int64) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return true
+	}
+	return (a*b)/b != a
+}
+
+// powOverflows повторяет intPow и проверяет каждое умножение. Лишнее
+// возведение в квадрат после последнего бита не выполняется.
+func powOverflows(a, b int64) bool {
+	res := int64(1)
+	for b > 0 {
+		if b&1 == 1 {
+			if mulOverflows(res, a) {
+				return true
+			}
+			res *= a
+		}
+		b >>= 1
+		if b > 0 {
+			if mulOverflows(a, a) {
+				return true
+			}
+			a *= a
+		}
+	}
+	return false
+}
+
+func FloatOp(op string, a, b float64) (float64, error) {
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		return a / b, nil
+	case "%":
+		return math.Mod(a, b), nil
+	case "^":
+		return math.Pow(a, b), nil
+	default:
+		return 0, fmt.Errorf("unknown float op %q", op)
+	}
+}
+
+// Concat — сложение строк: string + string, string + char, char + string.
+// false, если ни один из операндов не строка.
+func Concat(a, b Value) (Value, bool) {
+	as, ok := stringPart(a)
+	if !ok {
+		return Value{}, false
+	}
+	bs, ok := stringPart(b)
+	if !ok || (a.Kind != ValString && b.Kind != ValString) {
+		return Value{}, false
+	}
+	return Value{Kind: ValString, S: as + bs}, true
+}
+
+func stringPart(v Value) (string, bool) {
+	switch v.Kind {
+	case ValString:
+		return v.S, true
+	case ValChar:
+		return string([]byte{v.C}), true
+	default:
+		return "", false
+	}
+}
+
+// Convert — явное преобразование int/float/char, общее для VM и свертки.
+// float -> int отбрасывает дробную часть.
+func Convert(v Value, to TypeKind) (Value, error) {
+	switch to {
+	case TypeInt:
+		switch v.Kind {
+		case ValInt:
+			return v, nil
+		case ValChar:
+			return Value{Kind: ValInt, I: int64(v.C)}, nil
+		case ValFloat:
+			if math.IsNaN(v.F) || v.F >= math.MaxInt64 || v.F < math.MinInt64 {
+				return Value{}, fmt.Errorf("float %g out of int range", v.F)
+			}
+			return Value{Kind: ValInt, I: int64(v.F)}, nil
+		}
+	case TypeFloat:
+		switch v.Kind {
+		case ValFloat:
+			return v, nil
+		case ValInt:
+			return Value{Kind: ValFloat, F: float64(v.I)}, nil
+		}
+	case TypeChar:
+		switch v.Kind {
+		case ValChar:
+			return v, nil
+		case ValInt:
+			if v.I < 0 || v.I > math.MaxUint8 {
+				return Value{}, fmt.Errorf("int %d out of char range", v.I)
+			}
+			return Value{Kind: ValChar, C: byte(v.I)}, nil
+		}
+	}
+	return Value{}, fmt.Errorf("cannot convert %v to %v", v.Kind, to)
+}
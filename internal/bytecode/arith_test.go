@@ -0,0 +1,59 @@
+package bytecode_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+func TestIntPowExact(t *testing.T) {
+	tests := []struct {
+		a, b, want int64
+	}{
+		{3, 39, 4052555153018976267},
+		{2, 62, 1 << 62},
+		{-2, 63, math.MinInt64},
+		{7, 0, 1},
+		{0, 0, 1},
+		{-1, 1001, -1},
+	}
+	for _, tt := range tests {
+		got, err := bytecode.IntOp("^", tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("%d ^ %d = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+		if bytecode.IntOverflows("^", tt.a, tt.b) {
+			t.Errorf("%d ^ %d reported as overflow", tt.a, tt.b)
+		}
+	}
+	if _, err := bytecode.IntOp("^", 2, -1); err == nil {
+		t.Errorf("2 ^ -1 succeeded")
+	}
+}
+
+func TestIntOverflows(t *testing.T) {
+	tests := []struct {
+		op   string
+		a, b int64
+		want bool
+	}{
+		{"+", math.MaxInt64, 1, true},
+		{"+", math.MaxInt64, -1, false},
+		{"-", math.MinInt64, 1, true},
+		{"-", -1, math.MaxInt64, false},
+		{"*", math.MinInt64, -1, true},
+		{"*", 1 << 32, 1 << 31, true},
+		{"*", 1 << 31, 1 << 31, false},
+		{"/", math.MinInt64, -1, true},
+		{"^", 2, 63, true},
+		{"^", 3, 40, true},
+		{"^", 3, 39, false},
+		{"<<", 1, 63, false},
+	}
+	for _, tt := range tests {
+		if got := bytecode.IntOverflows(tt.op, tt.a, tt.b); got != tt.want {
+			t.Errorf("IntOverflows(%d %s %d) = %v, want %v", tt.a, tt.op, tt.b, got, tt.want)
+		}
+	}
+}
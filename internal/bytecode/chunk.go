@@ -1,15 +1,37 @@
 ﻿package bytecode
 
+import (
+	"cmp"
+	"slices"
+)
+
 type Chunk struct {
-	Code      []byte  // байткод(опкод+аргументы)
-	Constants []Value // слайс констант, к которым обращается opConst
+	Code       []byte      // байткод(опкод+аргументы)
+	Constants  []Value     // слайс констант, к которым обращается opConst
+	JumpTables []JumpTable // таблицы переходов OpJumpTable
+	Positions  []Position  // места в исходнике инструкций, которые падают на null
+}
+
+// Position — инструкция по смещению Offset в Code записана в исходнике
+// на позиции Pos. Записи идут по возрастанию Offset.
+type Position struct {
+	Offset int
+	Pos    int
+}
+
+// JumpTable — переходы switch по плотным int: значение v ведет на
+// Targets[v-Min], все остальное — на Default.
+type JumpTable struct {
+	Min     int64
+	Targets []int
+	Default int
 }
 
 func (c *Chunk) Write(op OpCode) {
 	c.Code = append(c.Code, byte(op))
 }
 
-func (c *Chunk) WriteByte(b byte) {
+func (c *Chunk) WriteUint8(b byte) {
 	c.Code = append(c.Code, b)
 }
 func (c *Chunk) WriteUint16(v uint16) {
@@ -25,3 +47,19 @@ func (c *Chunk) AddConstant(v Value) int {
 	c.Constants = append(c.Constants, v)
 	return len(c.Constants) - 1
 }
+
+// Mark запоминает позицию в исходнике следующей записанной инструкции.
+func (c *Chunk) Mark(pos int) {
+	c.Positions = append(c.Positions, Position{Offset: len(c.Code), Pos: pos})
+}
+
+// PosAt — позиция в исходнике инструкции по смещению offset.
+func (c *Chunk) PosAt(offset int) (int, bool) {
+	i, ok := slices.BinarySearchFunc(c.Positions, offset, func(p Position, offset int) int {
+		return cmp.Compare(p.Offset, offset)
+	})
+	if !ok {
+		return 0, false
+	}
+	return c.Positions[i].Pos, true
+}
@@ -6,10 +6,36 @@ type FunctionInfo struct {
 	ParamTypes []TypeKind
 	ReturnType TypeKind
 
-	Chunk     Chunk
-	NumLocals int
+	Chunk       Chunk
+	NumLocals   int
+	NumUpvalues int       // ячейки захвата идут в локалах сразу после параметров
+	Handlers    []Handler // обработчики исключений, вложенные раньше внешних
 }
 
+// Handler — ветка catch: исключение, брошенное инструкцией из [Start, End),
+// переходит на Target со значением на стеке, если подходит по типу.
+// TypeAny ловит любое значение, для TypeStruct сравнивается и имя.
+type Handler struct {
+	Start  int
+	End    int
+	Target int
+	Type   TypeKind
+	Struct string
+	Depth  int // значений на стеке операндов под try, они остаются при переходе в catch
+}
+
+type StructInfo struct {
+	Name       string
+	Fields     []string
+	FieldTypes []TypeKind
+}
+
+// InitFunction — функция, которую компилятор собирает из инициализаторов
+// глобальных переменных; VM выполняет ее один раз перед первым вызовом.
+const InitFunction = "$init"
+
 type Module struct {
 	Functions map[string]*FunctionInfo
+	Structs   map[string]*StructInfo
+	Globals   []string // имена глобальных переменных по номеру слота
 }
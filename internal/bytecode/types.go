@@ -1,5 +1,7 @@
 package bytecode
 
+import "strconv"
+
 type TypeKind byte
 
 const (
@@ -12,6 +14,11 @@ const (
 	TypeVoid
 	TypeNull
 	TypeArray
+	TypeStruct
+	TypeFunction
+	TypeAny // параметр типа обобщенной функции, стирается при компиляции
+	TypeList
+	TypeMap
 )
 
 type ValueKind byte
@@ -30,13 +37,21 @@ type ObjectType byte
 
 const (
 	ObjArray ObjectType = iota
+	ObjStruct
+	ObjClosure // значение функции: Fn и ячейки захваченных переменных в Items
+	ObjUpvalue // ячейка захваченной переменной: значение в Items[0]
+	ObjList    // list<T>: элементы в Items
+	ObjMap     // map<K, V>: пары в Map
 )
 
 type Object struct {
-	Mark  bool
-	Type  ObjectType
-	Next  *Object // односвязный список всех объектов в куче
-	Items []Value // для массивов: элементы
+	Mark   bool
+	Type   ObjectType
+	Next   *Object       // односвязный список всех объектов в куче
+	Items  []Value       // для массивов: элементы, для структур: поля по порядку объявления
+	Fn     *FunctionInfo // для замыканий
+	Struct *StructInfo   // для структур: тип, по нему выбирается ветка catch
+	Map    map[Value]Value
 }
 
 type Heap struct {
@@ -55,6 +70,49 @@ type Value struct {
 	Obj  *Object
 }
 
+// ZeroValue — начальное значение переменной типа t в новом массиве
+// или структуре: 0, 0.0, false, для ссылочных типов, включая строки, — null.
+func ZeroValue(t TypeKind) Value {
+	switch t {
+	case TypeInt:
+		return Value{Kind: ValInt}
+	case TypeFloat:
+		return Value{Kind: ValFloat}
+	case TypeBool:
+		return Value{Kind: ValBool}
+	case TypeChar:
+		return Value{Kind: ValChar}
+	default:
+		return Value{Kind: ValNull}
+	}
+}
+
+// FormatValue — значение так, как его печатает print и возвращает str.
+func FormatValue(v Value) string {
+	switch v.Kind {
+	case ValInt:
+		return strconv.FormatInt(v.I, 10)
+
+	case ValFloat:
+		return strconv.FormatFloat(v.F, 'g', -1, 64)
+
+	case ValBool:
+		return strconv.FormatBool(v.B)
+
+	case ValChar:
+		return string(v.C) // или string(v.C), если C byte
+
+	case ValString:
+		return v.S
+
+	case ValNull:
+		return "null"
+
+	default:
+		return "<invalid>"
+	}
+}
+
 type OpCode byte
 
 const (
@@ -68,6 +126,9 @@ const (
 	OpMod                      // остаток
 	OpPow                      // вовзедение в степень
 
+	OpLoadGlobal  // загрузить глобальную переменную на стек
+	OpStoreGlobal // сохранить вершину стека в глобальную переменную
+
 	OpEq // =
 	OpNe // !=
 	OpLt // <
@@ -78,16 +139,54 @@ const (
 	OpNeg // -
 	OpNot // !
 
+	OpBitAnd // &
+	OpBitOr  // |
+	OpBitXor // xor
+	OpBitNot // ~
+	OpShl    // <<
+	OpShr    // >>, арифметический сдвиг
+
+	OpToInt   // int(x): из float или char
+	OpToFloat // float(x): из int
+	OpToChar  // char(x): из int в диапазоне 0..255
+
 	OpJump        // безусловный переход по адрессу
 	OpJumpIfFalse // переход если вершина стека false
+	OpJumpTable   // снять int и перейти по таблице: операнд — номер в JumpTables
 	OpPop         // удаление вершины со стека
 
-	OpCall   // вызов функции
-	OpReturn // вернуть из функции
-
-	OpArrayNew // выделить память под массив
-	OpArrayGet // получит значение по индексу
-	OpArraySet // присовить значение по индексу
+	OpDup      // продублировать вершину стека
+	OpDup2     // продублировать два верхних значения: a b -> a b a b
+	OpIncLocal // прибавить к int-локалу знаковый байт: операнды слот и шаг
+
+	OpCall     // вызов функции
+	OpTailCall // вызов в хвостовой позиции: переиспользует текущий фрейм
+	OpReturn   // вернуть из функции
+	OpThrow    // бросить вершину стека как исключение
+
+	OpClosure      // создать замыкание: операнд — имя функции в константах, ячейки захвата на стеке
+	OpCallIndirect // вызвать замыкание под аргументами: операнд — число аргументов
+	OpCallNative   // вызвать встроенную функцию: операнды — номер в реестре native и число аргументов
+	OpNewCell      // положить вершину стека в новую ячейку (ObjUpvalue)
+	OpLoadCell     // значение из ячейки в локале
+	OpStoreCell    // записать вершину стека в ячейку в локале
+
+	OpArrayNew     // выделить память под массив
+	OpArrayLiteral // собрать массив из N значений со стека
+	OpArrayGet     // получит значение по индексу
+	OpArraySet     // присовить значение по индексу
+
+	OpArrayGetUnchecked // OpArrayGet без проверок: индекс доказанно в границах
+	OpArraySetUnchecked // OpArraySet без проверок
+
+	OpStructNew // создать структуру: операнд — имя в константах
+	OpGetField  // поле структуры по номеру
+	OpSetField  // записать поле структуры по номеру
+
+	OpLen        // длина массива или строки
+	OpStr        // значение -> строка, как его печатает print
+	OpParseInt   // строка -> int
+	OpParseFloat // строка -> float
 
 	OpArraySwapJit
 
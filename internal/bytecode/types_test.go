@@ -0,0 +1,24 @@
+package bytecode_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+)
+
+func TestZeroValue(t *testing.T) {
+	tests := []struct {
+		t    bytecode.TypeKind
+		want bytecode.ValueKind
+	}{
+		{bytecode.TypeInt, bytecode.ValInt},
+		{bytecode.TypeFloat, bytecode.ValFloat},
+		{bytecode.TypeBool, bytecode.ValBool},
+		{bytecode.TypeString, bytecode.ValNull},
+	}
+	for _, tt := range tests {
+		if got := bytecode.ZeroValue(tt.t).Kind; got != tt.want {
+			t.Errorf("ZeroValue(%v).Kind = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
@@ -22,31 +22,121 @@ type exprBase struct{}
 func (exprBase) exprNode() {}
 
 type Program struct {
+	Imports   []*ImportDecl
+	Structs   []*StructDecl
+	Enums     []*EnumDecl
+	Globals   []*VarDeclStmt // в порядке объявления, в нем же и инициализируются
 	Functions []*FunctionDecl
+	Library   bool // загружена через import: функция main не обязательна
+}
+
+// ImportDecl: import "lib/sort.easy" as sort. Путь — относительно
+// импортирующего файла, Alias по умолчанию — имя файла без расширения.
+type ImportDecl struct {
+	Path  string
+	Alias string
+}
+
+type StructDecl struct {
+	Name   string
+	Fields []Field
+}
+
+// EnumDecl: enum Color { Red, Green, Blue }. Значение константы — ее номер
+// в Members, парсер подставляет Color.Red как литерал 0 типа Color.
+type EnumDecl struct {
+	Name    string
+	Members []string
+}
+
+type Field struct {
+	Name string
+	Type types.Type
 }
 
 type FunctionDecl struct {
 	Name       string
+	TypeParams []types.Type // параметры типа обобщенной функции, Kind == TypeParam
 	Params     []Param
 	ReturnType types.Type
 	Body       *BlockStmt
+	Exported   bool   // export function — видна импортирующим файлам
+	Extern     string // имя при линковке функции другого модуля; тела у нее нет
 }
 
 type Param struct {
-	Name string
-	Type types.Type
+	Name     string
+	Type     types.Type
+	Captured bool // читается или пишется вложенной функцией, проставляет semantics.Checker
 }
 
 type IndexExpr struct {
 	exprBase
-	Array Expr
-	Index Expr
+	Array    Expr
+	Index    Expr
+	InBounds bool // доказано оптимизатором: индекс всегда в границах массива
+	Pos      int  // позиция '[' в исходнике, для ошибок времени выполнения
 }
 
+// NewArrayExpr — new T[a][b]...[]: Lengths — заданные размеры измерений,
+// Dims — число всех измерений, включая пустые [] в конце.
 type NewArrayExpr struct {
 	exprBase
-	ElementType types.Type
-	Length      Expr
+	ElementType types.Type // тип элементов самого внутреннего массива
+	Lengths     []Expr
+	Dims        int
+}
+
+// ArrayType — тип всего выражения, например int[][] для new int[3][4].
+func (e *NewArrayExpr) ArrayType() types.Type {
+	t := e.ElementType
+	for i := 0; i < e.Dims; i++ {
+		t = types.ArrayOf(t)
+	}
+	return t
+}
+
+// NewStructExpr — new Point: поля получают нулевые значения своих типов.
+type NewStructExpr struct {
+	exprBase
+	Name string
+}
+
+// NewCollectionExpr: new list<int> или new map<string, int> — пустая коллекция.
+type NewCollectionExpr struct {
+	exprBase
+	Type types.Type
+}
+
+type FieldExpr struct {
+	exprBase
+	Object Expr
+	Name   string
+	Index  int // номер поля в структуре, проставляет semantics.Checker
+	Pos    int // позиция '.' в исходнике
+}
+
+// CastExpr — явное преобразование float(i), int(x), char(n). Такие же узлы
+// вставляет semantics.Checker, когда int продвигается до float.
+type CastExpr struct {
+	exprBase
+	Type types.Type
+	Expr Expr
+}
+
+// FuncLitExpr — анонимная функция function(int x) int { ... }. Decl.Name
+// уникален в программе и задается парсером. Captures — имена локалов
+// объемлющих функций, которые использует тело (в том числе через
+// вложенные функции); их заполняет semantics.Checker.
+type FuncLitExpr struct {
+	exprBase
+	Decl     *FunctionDecl
+	Captures []string
+}
+
+type ArrayLiteralExpr struct {
+	exprBase
+	Elements []Expr
 }
 
 type IdentExpr struct {
@@ -78,13 +168,16 @@ type CallExpr struct {
 	exprBase
 	Callee Expr
 	Args   []Expr
+	Pos    int // позиция '(' в исходнике
 }
 
 type VarDeclStmt struct {
 	stmtBase
-	Name string
-	Type types.Type
-	Init Expr
+	Name     string
+	Type     types.Type
+	Init     Expr
+	Const    bool // только для глобальных: const int N = 100
+	Captured bool // читается или пишется вложенной функцией, проставляет semantics.Checker
 }
 
 type BlockStmt struct {
@@ -97,12 +190,64 @@ type ExprStmt struct {
 	Expr Expr
 }
 
+// AssignStmt — присваивание. Op задан для составного присваивания в элемент
+// массива или поле (a[f()] += v): цель вычисляется один раз, Value — правый
+// операнд. Для переменных парсер сразу разворачивает x += v в x = x + v.
 type AssignStmt struct {
 	stmtBase
 	Target Expr
+	Op     token.TokenType
 	Value  Expr
 }
 
+// SwitchStmt — switch (Subject) { case 1, 2: ... default: ... }. Ветки не
+// проваливаются одна в другую, break выходит из switch.
+type SwitchStmt struct {
+	stmtBase
+	Subject Expr
+	Cases   []*SwitchCase
+	Default *BlockStmt // nil, если default нет
+}
+
+type SwitchCase struct {
+	Values []Expr
+	Body   *BlockStmt
+}
+
+// Blocks — тела всех веток по порядку, default последним.
+func (s *SwitchStmt) Blocks() []*BlockStmt {
+	blocks := make([]*BlockStmt, 0, len(s.Cases)+1)
+	for _, c := range s.Cases {
+		blocks = append(blocks, c.Body)
+	}
+	if s.Default != nil {
+		blocks = append(blocks, s.Default)
+	}
+	return blocks
+}
+
+// TryStmt — try { ... } catch (string e) { ... } catch { ... }. Ветки catch
+// проверяются по порядку, подходит первая по типу брошенного значения.
+type TryStmt struct {
+	stmtBase
+	Body    *BlockStmt
+	Catches []*CatchClause
+}
+
+// CatchClause — ветка catch. Name == "" — catch без типа, ловит любое значение.
+type CatchClause struct {
+	Type     types.Type
+	Name     string
+	Captured bool // проставляет semantics.Checker, как у VarDeclStmt
+	Body     *BlockStmt
+}
+
+// ThrowStmt — throw Value: бросает значение любого типа.
+type ThrowStmt struct {
+	stmtBase
+	Value Expr
+}
+
 type ReturnStmt struct {
 	stmtBase
 	Value Expr
@@ -80,6 +80,14 @@ func (l *Lexer) readString() token.Token {
 		if c == 0 || c == '\n' || c == '"' {
 			break
 		}
+		if c == '\\' {
+			l.skipChar()
+			e, ok := unescape(l.currentChar())
+			if !ok {
+				return token.Token{Type: token.TokenInvalid, Text: string(buf), Pos: start}
+			}
+			c = e
+		}
 		buf = append(buf, c)
 		l.skipChar()
 	}
@@ -91,6 +99,49 @@ func (l *Lexer) readString() token.Token {
 	return token.Token{Type: token.TokenInvalid, Text: string(buf), Pos: start}
 }
 
+// unescape — символ после обратной косой черты: \n, \t, \r, \0, \\, \", \'.
+func unescape(c byte) (byte, bool) {
+	switch c {
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'r':
+		return '\r', true
+	case '0':
+		return 0, true
+	case '\\', '"', '\'':
+		return c, true
+	}
+	return 0, false
+}
+
+// readChar читает символьный литерал 'c' — ровно один байт.
+func (l *Lexer) readChar() token.Token {
+	start := l.position
+	l.skipChar()
+
+	c := l.currentChar()
+	if c == 0 || c == '\n' || c == '\'' {
+		return token.Token{Type: token.TokenInvalid, Text: "'", Pos: start}
+	}
+	if c == '\\' {
+		l.skipChar()
+		e, ok := unescape(l.currentChar())
+		if !ok {
+			return token.Token{Type: token.TokenInvalid, Text: "'", Pos: start}
+		}
+		c = e
+	}
+	l.skipChar()
+
+	if l.currentChar() != '\'' {
+		return token.Token{Type: token.TokenInvalid, Text: string(c), Pos: start}
+	}
+	l.skipChar()
+	return token.Token{Type: token.TokenCharText, Text: string(c), Pos: start}
+}
+
 func (l *Lexer) readIdentifier() token.Token {
 	start := l.position
 	var buf []byte
@@ -137,8 +188,20 @@ func (l *Lexer) readIdentifier() token.Token {
 		return token.Token{Type: token.TokenWhile, Text: ident, Pos: start}
 	case "for":
 		return token.Token{Type: token.TokenFor, Text: ident, Pos: start}
+	case "switch":
+		return token.Token{Type: token.TokenSwitch, Text: ident, Pos: start}
+	case "case":
+		return token.Token{Type: token.TokenCase, Text: ident, Pos: start}
+	case "default":
+		return token.Token{Type: token.TokenDefault, Text: ident, Pos: start}
 	case "return":
 		return token.Token{Type: token.TokenReturn, Text: ident, Pos: start}
+	case "try":
+		return token.Token{Type: token.TokenTry, Text: ident, Pos: start}
+	case "catch":
+		return token.Token{Type: token.TokenCatch, Text: ident, Pos: start}
+	case "throw":
+		return token.Token{Type: token.TokenThrow, Text: ident, Pos: start}
 	case "null":
 		return token.Token{Type: token.TokenNull, Text: ident, Pos: start}
 	case "true":
@@ -151,6 +214,18 @@ func (l *Lexer) readIdentifier() token.Token {
 		return token.Token{Type: token.TokenContinue, Text: ident, Pos: start}
 	case "new":
 		return token.Token{Type: token.TokenNew, Text: ident, Pos: start}
+	case "struct":
+		return token.Token{Type: token.TokenStruct, Text: ident, Pos: start}
+	case "const":
+		return token.Token{Type: token.TokenConst, Text: ident, Pos: start}
+	case "import":
+		return token.Token{Type: token.TokenImport, Text: ident, Pos: start}
+	case "export":
+		return token.Token{Type: token.TokenExport, Text: ident, Pos: start}
+	case "enum":
+		return token.Token{Type: token.TokenEnum, Text: ident, Pos: start}
+	case "xor":
+		return token.Token{Type: token.TokenXor, Text: ident, Pos: start}
 	default:
 		return token.Token{Type: token.TokenIdentifier, Text: ident, Pos: start}
 	}
@@ -177,6 +252,11 @@ func (l *Lexer) Tokenize() []token.Token {
 			continue
 		}
 
+		if c == '\'' {
+			tokens = append(tokens, l.readChar())
+			continue
+		}
+
 		if unicode.IsLetter(rune(c)) || c == '_' {
 			tokens = append(tokens, l.readIdentifier())
 			continue
@@ -194,6 +274,9 @@ func (l *Lexer) Tokenize() []token.Token {
 		case ';':
 			l.skipChar()
 			tokens = append(tokens, token.Token{Type: token.TokenSemicolon, Text: ";", Pos: l.position - 1})
+		case ':':
+			l.skipChar()
+			tokens = append(tokens, token.Token{Type: token.TokenColon, Text: ":", Pos: l.position - 1})
 		case '!':
 			l.skipChar()
 			if l.currentChar() == '=' {
@@ -207,6 +290,14 @@ func (l *Lexer) Tokenize() []token.Token {
 			if l.currentChar() == '=' {
 				l.skipChar()
 				tokens = append(tokens, token.Token{Type: token.TokenLessEqual, Text: "<=", Pos: l.position - 2})
+			} else if l.currentChar() == '<' {
+				l.skipChar()
+				if l.currentChar() == '=' {
+					l.skipChar()
+					tokens = append(tokens, token.Token{Type: token.TokenShiftLeftAssign, Text: "<<=", Pos: l.position - 3})
+				} else {
+					tokens = append(tokens, token.Token{Type: token.TokenShiftLeft, Text: "<<", Pos: l.position - 2})
+				}
 			} else {
 				tokens = append(tokens, token.Token{Type: token.TokenLess, Text: "<", Pos: l.position - 1})
 			}
@@ -215,6 +306,14 @@ func (l *Lexer) Tokenize() []token.Token {
 			if l.currentChar() == '=' {
 				l.skipChar()
 				tokens = append(tokens, token.Token{Type: token.TokenGreaterEqual, Text: ">=", Pos: l.position - 2})
+			} else if l.currentChar() == '>' {
+				l.skipChar()
+				if l.currentChar() == '=' {
+					l.skipChar()
+					tokens = append(tokens, token.Token{Type: token.TokenShiftRightAssign, Text: ">>=", Pos: l.position - 3})
+				} else {
+					tokens = append(tokens, token.Token{Type: token.TokenShiftRight, Text: ">>", Pos: l.position - 2})
+				}
 			} else {
 				tokens = append(tokens, token.Token{Type: token.TokenGreater, Text: ">", Pos: l.position - 1})
 			}
@@ -223,32 +322,72 @@ func (l *Lexer) Tokenize() []token.Token {
 			if l.currentChar() == '&' {
 				l.skipChar()
 				tokens = append(tokens, token.Token{Type: token.TokenAnd, Text: "&&", Pos: l.position - 2})
+			} else if l.currentChar() == '=' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenBitAndAssign, Text: "&=", Pos: l.position - 2})
 			} else {
-				tokens = append(tokens, token.Token{Type: token.TokenInvalid, Text: "&", Pos: l.position - 1})
+				tokens = append(tokens, token.Token{Type: token.TokenBitAnd, Text: "&", Pos: l.position - 1})
 			}
 		case '|':
 			l.skipChar()
 			if l.currentChar() == '|' {
 				l.skipChar()
 				tokens = append(tokens, token.Token{Type: token.TokenOr, Text: "||", Pos: l.position - 2})
+			} else if l.currentChar() == '=' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenBitOrAssign, Text: "|=", Pos: l.position - 2})
 			} else {
-				tokens = append(tokens, token.Token{Type: token.TokenInvalid, Text: "|", Pos: l.position - 1})
+				tokens = append(tokens, token.Token{Type: token.TokenBitOr, Text: "|", Pos: l.position - 1})
 			}
 		case '+':
 			l.skipChar()
-			tokens = append(tokens, token.Token{Type: token.TokenPlus, Text: "+", Pos: l.position - 1})
+			if l.currentChar() == '=' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenPlusAssign, Text: "+=", Pos: l.position - 2})
+			} else if l.currentChar() == '+' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenIncrement, Text: "++", Pos: l.position - 2})
+			} else {
+				tokens = append(tokens, token.Token{Type: token.TokenPlus, Text: "+", Pos: l.position - 1})
+			}
 		case '-':
 			l.skipChar()
-			tokens = append(tokens, token.Token{Type: token.TokenMinus, Text: "-", Pos: l.position - 1})
+			if l.currentChar() == '=' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenMinusAssign, Text: "-=", Pos: l.position - 2})
+			} else if l.currentChar() == '-' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenDecrement, Text: "--", Pos: l.position - 2})
+			} else {
+				tokens = append(tokens, token.Token{Type: token.TokenMinus, Text: "-", Pos: l.position - 1})
+			}
 		case '*':
 			l.skipChar()
-			tokens = append(tokens, token.Token{Type: token.TokenMultiply, Text: "*", Pos: l.position - 1})
+			if l.currentChar() == '=' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenMultiplyAssign, Text: "*=", Pos: l.position - 2})
+			} else {
+				tokens = append(tokens, token.Token{Type: token.TokenMultiply, Text: "*", Pos: l.position - 1})
+			}
 		case '/':
 			l.skipChar()
-			tokens = append(tokens, token.Token{Type: token.TokenDivide, Text: "/", Pos: l.position - 1})
+			if l.currentChar() == '=' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenDivideAssign, Text: "/=", Pos: l.position - 2})
+			} else {
+				tokens = append(tokens, token.Token{Type: token.TokenDivide, Text: "/", Pos: l.position - 1})
+			}
 		case '%':
 			l.skipChar()
-			tokens = append(tokens, token.Token{Type: token.TokenModulo, Text: "%", Pos: l.position - 1})
+			if l.currentChar() == '=' {
+				l.skipChar()
+				tokens = append(tokens, token.Token{Type: token.TokenModuloAssign, Text: "%=", Pos: l.position - 2})
+			} else {
+				tokens = append(tokens, token.Token{Type: token.TokenModulo, Text: "%", Pos: l.position - 1})
+			}
+		case '~':
+			l.skipChar()
+			tokens = append(tokens, token.Token{Type: token.TokenBitNot, Text: "~", Pos: l.position - 1})
 		case '^':
 			l.skipChar()
 			tokens = append(tokens, token.Token{Type: token.TokenPower, Text: "^", Pos: l.position - 1})
@@ -273,6 +412,9 @@ func (l *Lexer) Tokenize() []token.Token {
 		case ',':
 			l.skipChar()
 			tokens = append(tokens, token.Token{Type: token.TokenComma, Text: ",", Pos: l.position - 1})
+		case '.':
+			l.skipChar()
+			tokens = append(tokens, token.Token{Type: token.TokenDot, Text: ".", Pos: l.position - 1})
 		case '\n':
 			l.skipChar()
 			tokens = append(tokens, token.Token{Type: token.TokenNewline, Text: "\\n", Pos: l.position - 1})
@@ -0,0 +1,161 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+)
+
+// Unit — файл программы. Name — префикс имен его функций при линковке,
+// у корневого файла пустой.
+type Unit struct {
+	Path    string
+	Name    string
+	Program *ast.Program
+}
+
+// Loader читает файл программы и все, что он импортирует. Каждый файл
+// разбирается один раз, сколько бы файлов его ни импортировали.
+type Loader struct {
+	ReadFile func(name string) ([]byte, error)
+
+	units   map[string]*Unit
+	order   []*Unit
+	loading []string // цепочка импортов от корня до текущего файла
+	names   map[string]bool
+}
+
+func NewLoader() *Loader {
+	return &Loader{
+		ReadFile: os.ReadFile,
+		units:    make(map[string]*Unit),
+		names:    make(map[string]bool),
+	}
+}
+
+// Load возвращает файлы в порядке зависимостей: каждый идет после тех,
+// что он импортирует, корневой — последним.
+func (l *Loader) Load(path string) ([]*Unit, error) {
+	if _, err := l.load(filepath.Clean(path), true); err != nil {
+		return nil, err
+	}
+	return l.order, nil
+}
+
+func (l *Loader) load(path string, root bool) (*Unit, error) {
+	if i := slices.Index(l.loading, path); i >= 0 {
+		cycle := append(slices.Clone(l.loading[i:]), path)
+		return nil, fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+	}
+	if u, ok := l.units[path]; ok {
+		return u, nil
+	}
+
+	src, err := l.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := parse(path, string(src), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Unit{Path: path, Program: prog}
+	if !root {
+		u.Name = l.unitName(path)
+		prog.Library = true
+	}
+
+	l.loading = append(l.loading, path)
+	deps := make([]*Unit, len(prog.Imports))
+	var enums []*ast.EnumDecl
+	for i, imp := range prog.Imports {
+		dep, err := l.load(filepath.Join(filepath.Dir(path), filepath.FromSlash(imp.Path)), false)
+		if err != nil {
+			return nil, err
+		}
+		deps[i] = dep
+		enums = append(enums, dep.Program.Enums...)
+	}
+	l.loading = l.loading[:len(l.loading)-1]
+
+	// Color.Red разбирается как константа, только если парсер знает Color,
+	// поэтому файл с импортированными перечислениями разбирается заново
+	if len(enums) > 0 {
+		library := prog.Library
+		if prog, err = parse(path, string(src), enums); err != nil {
+			return nil, err
+		}
+		prog.Library = library
+		u.Program = prog
+	}
+	for i, imp := range prog.Imports {
+		declareImport(prog, deps[i], imp.Alias)
+	}
+
+	l.units[path] = u
+	l.order = append(l.order, u)
+	return u, nil
+}
+
+// parse разбирает файл; к ошибке разбора добавляется путь.
+// enums — перечисления импортированных файлов.
+func parse(path, src string, enums []*ast.EnumDecl) (prog *ast.Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", path, r)
+		}
+	}()
+	tokens := lexer.NewLexer(src).Tokenize()
+	p := parser.NewParser(tokens)
+	for _, e := range enums {
+		p.DeclareEnum(e)
+	}
+	return p.ParseProgram(), nil
+}
+
+// unitName — имя файла без расширения; одноименные файлы из разных
+// каталогов различаются номером.
+func (l *Loader) unitName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name := base
+	for i := 2; l.names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	l.names[name] = true
+	return name
+}
+
+// declareImport объявляет в prog экспортируемые функции модуля под именами
+// alias.f, а также структуры и перечисления модуля.
+func declareImport(prog *ast.Program, dep *Unit, alias string) {
+	for _, fn := range dep.Program.Functions {
+		if !fn.Exported {
+			continue
+		}
+		prog.Functions = append(prog.Functions, &ast.FunctionDecl{
+			Name:       alias + "." + fn.Name,
+			TypeParams: fn.TypeParams,
+			Params:     slices.Clone(fn.Params),
+			ReturnType: fn.ReturnType,
+			Extern:     dep.Name + "." + fn.Name,
+		})
+	}
+	for _, s := range dep.Program.Structs {
+		if !slices.Contains(prog.Structs, s) {
+			prog.Structs = append(prog.Structs, s)
+		}
+	}
+	for _, e := range dep.Program.Enums {
+		if !slices.Contains(prog.Enums, e) {
+			prog.Enums = append(prog.Enums, e)
+		}
+	}
+}
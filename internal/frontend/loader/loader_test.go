@@ -0,0 +1,76 @@
+package loader_test
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/loader"
+)
+
+// newLoader читает файлы из files вместо диска.
+func newLoader(files map[string]string) *loader.Loader {
+	l := loader.NewLoader()
+	l.ReadFile = func(name string) ([]byte, error) {
+		src, ok := files[filepath.ToSlash(name)]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		return []byte(src), nil
+	}
+	return l
+}
+
+func TestImportCycle(t *testing.T) {
+	_, err := newLoader(map[string]string{
+		"main.easy": "import \"a.easy\"\nfunction main() void {\n}\n",
+		"a.easy":    "import \"b.easy\"\n",
+		"b.easy":    "import \"a.easy\"\n",
+	}).Load("main.easy")
+	if err == nil || err.Error() != "import cycle: a.easy -> b.easy -> a.easy" {
+		t.Fatalf("err = %v, want import cycle a -> b -> a", err)
+	}
+}
+
+func TestSelfImport(t *testing.T) {
+	_, err := newLoader(map[string]string{
+		"main.easy": "import \"main.easy\"\nfunction main() void {\n}\n",
+	}).Load("main.easy")
+	if err == nil || err.Error() != "import cycle: main.easy -> main.easy" {
+		t.Fatalf("err = %v, want self import cycle", err)
+	}
+}
+
+func TestLoadOrder(t *testing.T) {
+	// ромб: main -> left, right -> util; util разбирается один раз
+	units, err := newLoader(map[string]string{
+		"main.easy":           "import \"lib/left.easy\"\nimport \"lib/right.easy\"\nfunction main() void {\n}\n",
+		"lib/left.easy":       "import \"util.easy\"\n",
+		"lib/right.easy":      "import \"util.easy\"\nimport \"other/util.easy\" as outil\n",
+		"lib/util.easy":       "",
+		"lib/other/util.easy": "",
+	}).Load("main.easy")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	var got [][2]string
+	for _, u := range units {
+		got = append(got, [2]string{filepath.ToSlash(u.Path), u.Name})
+	}
+	want := [][2]string{
+		{"lib/util.easy", "util"},
+		{"lib/left.easy", "left"},
+		{"lib/other/util.easy", "util2"},
+		{"lib/right.easy", "right"},
+		{"main.easy", ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("units %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("units %v, want %v", got, want)
+		}
+	}
+}
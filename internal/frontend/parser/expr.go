@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
@@ -9,120 +11,51 @@ import (
 	"github.com/ChernykhITMO/compiler/internal/frontend/types"
 )
 
-func (p *Parser) parseExpression() ast.Expr {
-	return p.parseLogicalOr()
-}
-
-func (p *Parser) parseLogicalOr() ast.Expr {
-	expr := p.parseLogicalAnd()
-
-	for p.match(token.TokenOr) {
-		op := p.previous()
-		right := p.parseLogicalAnd()
-		expr = &ast.BinaryExpr{
-			Left:  expr,
-			Op:    op.Type,
-			Right: right,
-		}
-	}
-
-	return expr
+// binaryLevels — приоритеты бинарных операторов, от слабого к сильному.
+// Все операторы левоассоциативны. Битовые операции связывают сильнее
+// сравнений, поэтому x & 1 == 0 читается как (x & 1) == 0.
+//
+//	||
+//	&&
+//	==  !=
+//	<  <=  >  >=
+//	|
+//	xor
+//	&
+//	<<  >>
+//	+  -
+//	*  /  %
+//	^            возведение в степень
+//
+// Унарные -, ! и ~ сильнее любого бинарного оператора.
+var binaryLevels = [][]token.TokenType{
+	{token.TokenOr},
+	{token.TokenAnd},
+	{token.TokenEqual, token.TokenNotEqual},
+	{token.TokenLess, token.TokenLessEqual, token.TokenGreater, token.TokenGreaterEqual},
+	{token.TokenBitOr},
+	{token.TokenXor},
+	{token.TokenBitAnd},
+	{token.TokenShiftLeft, token.TokenShiftRight},
+	{token.TokenPlus, token.TokenMinus},
+	{token.TokenMultiply, token.TokenDivide, token.TokenModulo},
+	{token.TokenPower},
 }
 
-func (p *Parser) parseLogicalAnd() ast.Expr {
-	expr := p.parseEquality()
-
-	for p.match(token.TokenAnd) {
-		op := p.previous()
-		right := p.parseEquality()
-		expr = &ast.BinaryExpr{
-			Left:  expr,
-			Op:    op.Type,
-			Right: right,
-		}
-	}
-	return expr
-}
-
-func (p *Parser) parseEquality() ast.Expr {
-	expr := p.parseComparison()
-
-	for p.match(token.TokenEqual) || p.match(token.TokenNotEqual) {
-		op := p.previous()
-		right := p.parseComparison()
-		expr = &ast.BinaryExpr{
-			Left:  expr,
-			Op:    op.Type,
-			Right: right,
-		}
-	}
-
-	return expr
-}
-
-func (p *Parser) parseComparison() ast.Expr {
-	expr := p.parseTerm()
-
-	for {
-		if p.check(token.TokenLess) || p.check(token.TokenLessEqual) ||
-			p.check(token.TokenGreater) || p.check(token.TokenGreaterEqual) {
-
-			op := p.advance()
-			right := p.parseTerm()
-			expr = &ast.BinaryExpr{
-				Left:  expr,
-				Op:    op.Type,
-				Right: right,
-			}
-		} else {
-			break
-		}
-	}
-
-	return expr
-}
-
-func (p *Parser) parseTerm() ast.Expr {
-	expr := p.parseFactor()
-
-	for p.match(token.TokenPlus) || p.match(token.TokenMinus) {
-		op := p.previous()
-		right := p.parseFactor()
-		expr = &ast.BinaryExpr{
-			Left:  expr,
-			Op:    op.Type,
-			Right: right,
-		}
-	}
-
-	return expr
+func (p *Parser) parseExpression() ast.Expr {
+	return p.parseBinary(0)
 }
 
-func (p *Parser) parseFactor() ast.Expr {
-	expr := p.parsePower()
-
-	for p.match(token.TokenMultiply) ||
-		p.match(token.TokenDivide) ||
-		p.match(token.TokenModulo) {
-
-		op := p.previous()
-		right := p.parsePower()
-		expr = &ast.BinaryExpr{
-			Left:  expr,
-			Op:    op.Type,
-			Right: right,
-		}
+func (p *Parser) parseBinary(level int) ast.Expr {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
 	}
 
-	return expr
-}
-
-func (p *Parser) parsePower() ast.Expr {
-	expr := p.parseUnary()
+	expr := p.parseBinary(level + 1)
 
-	for p.match(token.TokenPower) {
+	for p.matchAny(binaryLevels[level]) {
 		op := p.previous()
-		right := p.parseUnary()
+		right := p.parseBinary(level + 1)
 		expr = &ast.BinaryExpr{
 			Left:  expr,
 			Op:    op.Type,
@@ -134,7 +67,7 @@ func (p *Parser) parsePower() ast.Expr {
 }
 
 func (p *Parser) parseUnary() ast.Expr {
-	if p.match(token.TokenNot) || p.match(token.TokenMinus) {
+	if p.match(token.TokenNot) || p.match(token.TokenMinus) || p.match(token.TokenBitNot) {
 		op := p.previous()
 		right := p.parseUnary()
 		return &ast.UnaryExpr{
@@ -150,6 +83,7 @@ func (p *Parser) parseCall() ast.Expr {
 
 	for {
 		if p.match(token.TokenLeftParen) {
+			pos := p.previous().Pos
 			var args []ast.Expr
 			if !p.check(token.TokenRightParen) {
 				for {
@@ -163,13 +97,34 @@ func (p *Parser) parseCall() ast.Expr {
 			expr = &ast.CallExpr{
 				Callee: expr,
 				Args:   args,
+				Pos:    pos,
 			}
 		} else if p.match(token.TokenLeftBracket) {
+			pos := p.previous().Pos
 			indexExpr := p.parseExpression()
 			p.consume(token.TokenRightBracket, "expected ']' after index")
 			expr = &ast.IndexExpr{
 				Array: expr,
 				Index: indexExpr,
+				Pos:   pos,
+			}
+		} else if p.match(token.TokenDot) {
+			pos := p.previous().Pos
+			nameTok := p.consume(token.TokenIdentifier, "expected field name after '.'")
+			// sort.bubbleSort — функция импортированного модуля
+			if id, ok := expr.(*ast.IdentExpr); ok && p.imports[id.Name] {
+				expr = &ast.IdentExpr{Name: id.Name + "." + nameTok.Text}
+				continue
+			}
+			// Color.Red — константа перечисления, ее номер
+			if id, ok := expr.(*ast.IdentExpr); ok && p.enums[id.Name] != nil {
+				expr = p.enumMember(id.Name, nameTok)
+				continue
+			}
+			expr = &ast.FieldExpr{
+				Object: expr,
+				Name:   nameTok.Text,
+				Pos:    pos,
 			}
 		} else {
 			break
@@ -203,6 +158,15 @@ func (p *Parser) parsePrimary() ast.Expr {
 		}
 	}
 
+	if p.match(token.TokenCharText) {
+		t := p.previous()
+		return &ast.LiteralExpr{
+			Lexeme: t.Text,
+			Token:  t.Type,
+			Type:   types.TypeFromToken(token.TokenChar),
+		}
+	}
+
 	if p.match(token.TokenFalse) || p.match(token.TokenTrue) {
 		t := p.previous()
 		return &ast.LiteralExpr{
@@ -221,16 +185,63 @@ func (p *Parser) parsePrimary() ast.Expr {
 		}
 	}
 
+	if (p.check(token.TokenInt) || p.check(token.TokenFloat) || p.check(token.TokenChar)) &&
+		p.peek(1) == token.TokenLeftParen {
+		t := types.TypeFromToken(p.advance().Type)
+		p.advance()
+		expr := p.parseExpression()
+		p.consume(token.TokenRightParen, "expected ')' after conversion")
+		return &ast.CastExpr{Type: t, Expr: expr}
+	}
+
+	if p.match(token.TokenFunction) {
+		return p.parseFuncLit()
+	}
+
 	if p.match(token.TokenNew) {
 		elemType := p.parseBaseTypeName()
+		if elemType.Kind == types.TypeStruct && !p.check(token.TokenLeftBracket) {
+			return &ast.NewStructExpr{Name: elemType.Name}
+		}
+		if (elemType.Kind == types.TypeList || elemType.Kind == types.TypeMap) && !p.check(token.TokenLeftBracket) {
+			return &ast.NewCollectionExpr{Type: elemType}
+		}
 		p.consume(token.TokenLeftBracket, "expected '[' after type in new expression")
-		lenExpr := p.parseExpression()
+		arr := &ast.NewArrayExpr{ElementType: elemType}
+		arr.Lengths = append(arr.Lengths, p.parseExpression())
 		p.consume(token.TokenRightBracket, "expected ']' after length expression")
+		arr.Dims = 1
+
+		// new int[3][4] — все измерения заданы, new int[3][] — рваный массив;
+		// после первого пустого [] размеры уже не допускаются
+		for p.match(token.TokenLeftBracket) {
+			arr.Dims++
+			if p.match(token.TokenRightBracket) {
+				continue
+			}
+			if len(arr.Lengths) != arr.Dims-1 {
+				cur := p.current()
+				panic(fmt.Errorf("parse error at pos %d: array length after '[]' in new expression", cur.Pos))
+			}
+			arr.Lengths = append(arr.Lengths, p.parseExpression())
+			p.consume(token.TokenRightBracket, "expected ']' after length expression")
+		}
+
+		return arr
+	}
 
-		return &ast.NewArrayExpr{
-			ElementType: elemType,
-			Length:      lenExpr,
+	if p.match(token.TokenLeftBracket) {
+		lit := &ast.ArrayLiteralExpr{}
+		if !p.check(token.TokenRightBracket) {
+			for {
+				lit.Elements = append(lit.Elements, p.parseExpression())
+				if !p.match(token.TokenComma) {
+					break
+				}
+			}
 		}
+		p.consume(token.TokenRightBracket, "expected ']' after array literal")
+		return lit
 	}
 
 	if p.match(token.TokenIdentifier) {
@@ -246,3 +257,15 @@ func (p *Parser) parsePrimary() ast.Expr {
 
 	panic(fmt.Errorf("parse error at pos %d: expected expression", p.current().Pos))
 }
+
+func (p *Parser) enumMember(enum string, nameTok token.Token) *ast.LiteralExpr {
+	i := slices.Index(p.enums[enum], nameTok.Text)
+	if i < 0 {
+		panic(fmt.Errorf("parse error at pos %d: enum %s has no member '%s'", nameTok.Pos, enum, nameTok.Text))
+	}
+	return &ast.LiteralExpr{
+		Lexeme: strconv.Itoa(i),
+		Token:  token.TokenNumber,
+		Type:   types.EnumOf(enum),
+	}
+}
@@ -0,0 +1,75 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+	"github.com/ChernykhITMO/compiler/internal/frontend/token"
+)
+
+var symbols = map[token.TokenType]string{
+	token.TokenOr:         "||",
+	token.TokenAnd:        "&&",
+	token.TokenEqual:      "==",
+	token.TokenLess:       "<",
+	token.TokenBitOr:      "|",
+	token.TokenXor:        "xor",
+	token.TokenBitAnd:     "&",
+	token.TokenShiftLeft:  "<<",
+	token.TokenShiftRight: ">>",
+	token.TokenPlus:       "+",
+	token.TokenMinus:      "-",
+	token.TokenMultiply:   "*",
+	token.TokenPower:      "^",
+	token.TokenBitNot:     "~",
+}
+
+// group расставляет скобки вокруг каждой операции разобранного выражения.
+func group(e ast.Expr) string {
+	switch ex := e.(type) {
+	case *ast.IdentExpr:
+		return ex.Name
+	case *ast.LiteralExpr:
+		return ex.Lexeme
+	case *ast.UnaryExpr:
+		return "(" + symbols[ex.Op] + group(ex.Expr) + ")"
+	case *ast.BinaryExpr:
+		return "(" + group(ex.Left) + " " + symbols[ex.Op] + " " + group(ex.Right) + ")"
+	}
+	return "?"
+}
+
+// parseExpr разбирает выражение как значение return.
+func parseExpr(t *testing.T, src string) ast.Expr {
+	t.Helper()
+	prog := parser.NewParser(lexer.NewLexer("function f(int x, int y, int z) int {\n    return " + src + "\n}\n").Tokenize()).ParseProgram()
+	ret, ok := prog.Functions[0].Body.Statements[0].(*ast.ReturnStmt)
+	if !ok {
+		t.Fatalf("%s: not parsed as return", src)
+	}
+	return ret.Value
+}
+
+func TestBinaryPrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"x & 1 == 0", "((x & 1) == 0)"},
+		{"x | y xor z & 1", "(x | (y xor (z & 1)))"},
+		{"x << 1 + y", "(x << (1 + y))"},
+		{"x < y | z", "(x < (y | z))"},
+		{"x - y - z", "((x - y) - z)"},
+		{"x >> y << z", "((x >> y) << z)"},
+		{"~x & y", "((~x) & y)"},
+		{"x * y ^ 2", "(x * (y ^ 2))"},
+		{"x == 1 || y == 2 && z == 3", "((x == 1) || ((y == 2) && (z == 3)))"},
+	}
+	for _, tt := range tests {
+		if got := group(parseExpr(t, tt.src)); got != tt.want {
+			t.Errorf("%s: parsed as %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
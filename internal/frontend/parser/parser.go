@@ -2,7 +2,10 @@ package parser
 
 import (
 	"fmt"
+	"path"
+	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
 	"github.com/ChernykhITMO/compiler/internal/frontend/token"
@@ -12,10 +15,22 @@ import (
 type Parser struct {
 	tokens []token.Token
 	pos    int
+
+	fnName     string                // объемлющая функция — для имен анонимных функций
+	lambdas    int                   // счетчик анонимных функций в программе
+	typeParams map[string]types.Type // параметры типа объемлющей обобщенной функции
+	imports    map[string]bool       // имена импортированных модулей
+	enums      map[string][]string   // перечисления файла и модулей: имя -> константы
 }
 
 func NewParser(tokens []token.Token) *Parser {
-	return &Parser{tokens: tokens, pos: 0}
+	return &Parser{tokens: tokens, pos: 0, imports: make(map[string]bool), enums: make(map[string][]string)}
+}
+
+// DeclareEnum делает перечисление другого файла видимым в этом:
+// его имя — тип, а Color.Red — константа.
+func (p *Parser) DeclareEnum(decl *ast.EnumDecl) {
+	p.enums[decl.Name] = decl.Members
 }
 
 func (p *Parser) current() token.Token {
@@ -48,6 +63,15 @@ func (p *Parser) advance() token.Token {
 	return p.previous()
 }
 
+func (p *Parser) matchAny(types []token.TokenType) bool {
+	for _, tt := range types {
+		if p.match(tt) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Parser) match(tt token.TokenType) bool {
 	if p.check(tt) {
 		_ = p.advance()
@@ -65,31 +89,11 @@ func (p *Parser) consume(tt token.TokenType, msg string) token.Token {
 }
 
 func (p *Parser) parseTypeName() types.Type {
-	var base types.Type
-	switch {
-	case p.match(token.TokenInt):
-		base = types.TypeFromToken(token.TokenInt)
-	case p.match(token.TokenFloat):
-		base = types.TypeFromToken(token.TokenFloat)
-	case p.match(token.TokenString):
-		base = types.TypeFromToken(token.TokenString)
-	case p.match(token.TokenBool):
-		base = types.TypeFromToken(token.TokenBool)
-	case p.match(token.TokenChar):
-		base = types.TypeFromToken(token.TokenChar)
-	case p.match(token.TokenVoid):
-		base = types.TypeFromToken(token.TokenVoid)
-	default:
-		cur := p.current()
-		panic(fmt.Errorf("parse error at pos %d: expected types name", cur.Pos))
-	}
+	base := p.parseBaseTypeName()
 
 	for p.match(token.TokenLeftBracket) {
 		p.consume(token.TokenRightBracket, "expected ']' after '[' in array type")
-		base = types.Type{
-			Kind: types.TypeArray,
-			Elem: &base,
-		}
+		base = types.ArrayOf(base)
 	}
 
 	return base
@@ -109,14 +113,89 @@ func (p *Parser) parseBaseTypeName() types.Type {
 		return types.TypeFromToken(token.TokenChar)
 	case p.match(token.TokenVoid):
 		return types.TypeFromToken(token.TokenVoid)
+	case p.match(token.TokenFunction):
+		// function(int, float) int — тип результата обязателен
+		p.consume(token.TokenLeftParen, "expected '(' after 'function' in type")
+		var params []types.Type
+		if !p.check(token.TokenRightParen) {
+			for {
+				params = append(params, p.parseTypeName())
+				if !p.match(token.TokenComma) {
+					break
+				}
+			}
+		}
+		p.consume(token.TokenRightParen, "expected ')' after parameter types")
+		return types.FuncOf(params, p.parseTypeName())
+	case p.match(token.TokenIdentifier):
+		name := p.previous().Text
+		if _, ok := p.enums[name]; ok {
+			return types.EnumOf(name)
+		}
+		if tp, ok := p.typeParams[name]; ok {
+			return tp
+		}
+		if isCollection(name) && p.match(token.TokenLess) {
+			return p.parseCollectionType(name)
+		}
+		return types.StructOf(name)
 	default:
 		cur := p.current()
 		panic(fmt.Errorf("parse error at pos %d: expected type name", cur.Pos))
 	}
 }
 
+func isCollection(name string) bool {
+	return name == "list" || name == "map"
+}
+
+// parseCollectionType: list<T> или map<K, V> после '<'.
+func (p *Parser) parseCollectionType(name string) types.Type {
+	elem := p.parseTypeName()
+	t := types.ListOf(elem)
+	if name == "map" {
+		p.consume(token.TokenComma, "expected ',' after map key type")
+		t = types.MapOf(elem, p.parseTypeName())
+	}
+
+	// '>>' в list<list<int>> — две закрывающие скобки
+	if p.check(token.TokenShiftRight) {
+		p.tokens[p.pos].Type = token.TokenGreater
+		p.tokens[p.pos].Pos++
+		return t
+	}
+	p.consume(token.TokenGreater, "expected '>' after type arguments")
+	return t
+}
+
+// peek — тип токена на offset позиций впереди текущего.
+func (p *Parser) peek(offset int) token.TokenType {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset].Type
+	}
+	return token.TokenEnd
+}
+
+// isStructTypeStart: объявление вида Point p, Point[] ps или list<int> xs,
+// а не выражение, начинающееся с идентификатора.
+func (p *Parser) isStructTypeStart() bool {
+	if !p.check(token.TokenIdentifier) {
+		return false
+	}
+	switch p.peek(1) {
+	case token.TokenIdentifier:
+		return true
+	case token.TokenLeftBracket:
+		return p.peek(2) == token.TokenRightBracket
+	case token.TokenLess:
+		return isCollection(p.current().Text)
+	}
+	return false
+}
+
 func (p *Parser) ParseProgram() *ast.Program {
 	prog := &ast.Program{}
+	p.scanEnums()
 
 	for !p.isAtEnd() {
 		for p.match(token.TokenNewline) {
@@ -124,25 +203,219 @@ func (p *Parser) ParseProgram() *ast.Program {
 		if p.isAtEnd() {
 			break
 		}
+		p.typeParams = nil
+		if p.match(token.TokenImport) {
+			if len(prog.Structs)+len(prog.Enums)+len(prog.Globals)+len(prog.Functions) > 0 {
+				cur := p.previous()
+				panic(fmt.Errorf("parse error at pos %d: imports must precede declarations", cur.Pos))
+			}
+			imp := p.parseImport()
+			if p.imports[imp.Alias] {
+				cur := p.previous()
+				panic(fmt.Errorf("parse error at pos %d: module '%s' is already imported", cur.Pos, imp.Alias))
+			}
+			p.imports[imp.Alias] = true
+			prog.Imports = append(prog.Imports, imp)
+			continue
+		}
+		if p.match(token.TokenExport) {
+			if !p.check(token.TokenFunction) || p.peek(1) == token.TokenLeftParen {
+				cur := p.current()
+				panic(fmt.Errorf("parse error at pos %d: only functions can be exported", cur.Pos))
+			}
+			fn := p.parseFunction()
+			fn.Exported = true
+			prog.Functions = append(prog.Functions, fn)
+			continue
+		}
+		if p.match(token.TokenStruct) {
+			prog.Structs = append(prog.Structs, p.parseStruct())
+			continue
+		}
+		if p.match(token.TokenEnum) {
+			prog.Enums = append(prog.Enums, p.parseEnum())
+			continue
+		}
+		// function(int) int f = ... — глобальная переменная, а не функция
+		if !p.check(token.TokenFunction) || p.peek(1) == token.TokenLeftParen {
+			p.fnName = ""
+			prog.Globals = append(prog.Globals, p.parseGlobal())
+			continue
+		}
 		prog.Functions = append(prog.Functions, p.parseFunction())
 	}
 
 	return prog
 }
 
+// scanEnums заранее собирает перечисления файла: тип Color и константа
+// Color.Red могут встретиться раньше объявления enum Color.
+func (p *Parser) scanEnums() {
+	start := p.pos
+	for i, tok := range p.tokens {
+		if tok.Type == token.TokenEnum {
+			p.pos = i + 1
+			p.DeclareEnum(p.parseEnum())
+		}
+	}
+	p.pos = start
+}
+
+// parseEnum: enum Color { Red, Green, Blue } — константы разделяются
+// запятыми или переводами строк.
+func (p *Parser) parseEnum() *ast.EnumDecl {
+	nameTok := p.consume(token.TokenIdentifier, "expected enum name")
+	decl := &ast.EnumDecl{Name: nameTok.Text}
+
+	for p.match(token.TokenNewline) {
+	}
+	p.consume(token.TokenLeftBrace, "expected '{' after enum name")
+
+	for !p.check(token.TokenRightBrace) && !p.isAtEnd() {
+		if p.match(token.TokenNewline) || p.match(token.TokenComma) {
+			continue
+		}
+		memberTok := p.consume(token.TokenIdentifier, "expected enum member name")
+		if slices.Contains(decl.Members, memberTok.Text) {
+			panic(fmt.Errorf("parse error at pos %d: duplicate member '%s' in enum %s", memberTok.Pos, memberTok.Text, decl.Name))
+		}
+		decl.Members = append(decl.Members, memberTok.Text)
+	}
+
+	if len(decl.Members) == 0 {
+		panic(fmt.Errorf("parse error at pos %d: enum %s has no members", nameTok.Pos, decl.Name))
+	}
+	p.consume(token.TokenRightBrace, "expected '}' to end enum")
+	p.match(token.TokenNewline)
+
+	return decl
+}
+
+// parseImport: import "lib/sort.easy" или import "lib/sort.easy" as s.
+// Без as модуль виден под именем файла без расширения.
+func (p *Parser) parseImport() *ast.ImportDecl {
+	pathTok := p.consume(token.TokenText, "expected path string after 'import'")
+	decl := &ast.ImportDecl{Path: pathTok.Text}
+
+	if p.check(token.TokenIdentifier) && p.current().Text == "as" {
+		p.advance()
+		decl.Alias = p.consume(token.TokenIdentifier, "expected module name after 'as'").Text
+		return decl
+	}
+
+	decl.Alias = strings.TrimSuffix(path.Base(decl.Path), path.Ext(decl.Path))
+	if !isIdentifier(decl.Alias) {
+		panic(fmt.Errorf("parse error at pos %d: module name '%s' is not an identifier, use 'as'", pathTok.Pos, decl.Alias))
+	}
+	return decl
+}
+
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// parseGlobal: int counter = 0 или const int N = 100 на верхнем уровне.
+func (p *Parser) parseGlobal() *ast.VarDeclStmt {
+	isConst := p.match(token.TokenConst)
+	decl := p.parseVarDeclOrExprStmt().(*ast.VarDeclStmt)
+	decl.Const = isConst
+	if isConst && decl.Init == nil {
+		cur := p.previous()
+		panic(fmt.Errorf("parse error at pos %d: const '%s' must be initialized", cur.Pos, decl.Name))
+	}
+	return decl
+}
+
+// parseStruct: struct Point { int x  int y } — поля разделяются
+// пробелами, переводами строк или запятыми.
+func (p *Parser) parseStruct() *ast.StructDecl {
+	nameTok := p.consume(token.TokenIdentifier, "expected struct name")
+	decl := &ast.StructDecl{Name: nameTok.Text}
+
+	for p.match(token.TokenNewline) {
+	}
+	p.consume(token.TokenLeftBrace, "expected '{' after struct name")
+
+	for !p.check(token.TokenRightBrace) && !p.isAtEnd() {
+		if p.match(token.TokenNewline) || p.match(token.TokenComma) {
+			continue
+		}
+		fieldType := p.parseTypeName()
+		fieldTok := p.consume(token.TokenIdentifier, "expected field name")
+		decl.Fields = append(decl.Fields, ast.Field{
+			Name: fieldTok.Text,
+			Type: fieldType,
+		})
+	}
+
+	p.consume(token.TokenRightBrace, "expected '}' to end struct")
+	p.match(token.TokenNewline)
+
+	return decl
+}
+
 func (p *Parser) parseFunction() *ast.FunctionDecl {
 	p.consume(token.TokenFunction, "expected 'function'")
 	nameTok := p.consume(token.TokenIdentifier, "expected function name")
 
 	fn := &ast.FunctionDecl{Name: nameTok.Text}
+	p.fnName = fn.Name
+	if p.match(token.TokenLess) {
+		fn.TypeParams = p.parseTypeParams()
+	}
 
 	p.consume(token.TokenLeftParen, "expected '(' after function name")
+	fn.Params = p.parseParams()
+
+	if p.isTypeStart() {
+		fn.ReturnType = p.parseTypeName()
+	} else {
+		fn.ReturnType = types.Type{Kind: types.TypeVoid} // Надо подумать, убрать ли в конце функции тип
+		// (UPD: НЕ УБИРАЙ!! В ВАЛИДАТОРЕ ИДЕТ ПРОВЕРКА)
+		// Каждая фукнкция если не задана на тип будет войдовской
+	}
+
+	fn.Body = p.parseBlock()
+	p.match(token.TokenNewline) // опциональный \n после функции
 
+	return fn
+}
+
+// parseTypeParams: <T, U: ordered> без открывающей скобки. Без ограничения
+// параметр принимает любой тип.
+func (p *Parser) parseTypeParams() []types.Type {
+	var params []types.Type
+	p.typeParams = make(map[string]types.Type)
+	for {
+		nameTok := p.consume(token.TokenIdentifier, "expected type parameter name")
+		constraint := "any"
+		if p.match(token.TokenColon) {
+			constraint = p.consume(token.TokenIdentifier, "expected constraint after ':'").Text
+		}
+		tp := types.ParamOf(nameTok.Text, constraint)
+		params = append(params, tp)
+		p.typeParams[tp.Name] = tp
+		if !p.match(token.TokenComma) {
+			break
+		}
+	}
+	p.consume(token.TokenGreater, "expected '>' after type parameters")
+	return params
+}
+
+// parseParams: (int a, float b) без открывающей скобки.
+func (p *Parser) parseParams() []ast.Param {
+	var params []ast.Param
 	if !p.check(token.TokenRightParen) {
 		for {
 			parseType := p.parseTypeName()
 			paramNameTok := p.consume(token.TokenIdentifier, "expected parameter name")
-			fn.Params = append(fn.Params, ast.Param{
+			params = append(params, ast.Param{
 				Name: paramNameTok.Text,
 				Type: parseType,
 			})
@@ -153,21 +426,33 @@ func (p *Parser) parseFunction() *ast.FunctionDecl {
 		}
 	}
 	p.consume(token.TokenRightParen, "expected ')' after parameters")
+	return params
+}
 
-	if p.check(token.TokenInt) || p.check(token.TokenFloat) ||
+func (p *Parser) isTypeStart() bool {
+	return p.check(token.TokenInt) || p.check(token.TokenFloat) ||
 		p.check(token.TokenString) || p.check(token.TokenBool) ||
-		p.check(token.TokenChar) || p.check(token.TokenVoid) {
-		fn.ReturnType = p.parseTypeName()
+		p.check(token.TokenChar) || p.check(token.TokenVoid) ||
+		p.check(token.TokenIdentifier) || p.check(token.TokenFunction)
+}
+
+// parseFuncLit: function(int x) int { ... } после 'function'.
+// Без типа результата функция void.
+func (p *Parser) parseFuncLit() *ast.FuncLitExpr {
+	p.lambdas++
+	fn := &ast.FunctionDecl{Name: fmt.Sprintf("%s$%d", p.fnName, p.lambdas)}
+
+	p.consume(token.TokenLeftParen, "expected '(' after 'function'")
+	fn.Params = p.parseParams()
+
+	if p.check(token.TokenLeftBrace) {
+		fn.ReturnType = types.Type{Kind: types.TypeVoid}
 	} else {
-		fn.ReturnType = types.Type{Kind: types.TypeVoid} // Надо подумать, убрать ли в конце функции тип
-		// (UPD: НЕ УБИРАЙ!! В ВАЛИДАТОРЕ ИДЕТ ПРОВЕРКА)
-		// Каждая фукнкция если не задана на тип будет войдовской
+		fn.ReturnType = p.parseTypeName()
 	}
-
 	fn.Body = p.parseBlock()
-	p.match(token.TokenNewline) // опциональный \n после функции
 
-	return fn
+	return &ast.FuncLitExpr{Decl: fn}
 }
 
 func (p *Parser) parseBlock() *ast.BlockStmt {
@@ -191,6 +476,30 @@ func (p *Parser) parseBlock() *ast.BlockStmt {
 }
 
 func PrintProgram(prog *ast.Program) {
+	for _, imp := range prog.Imports {
+		fmt.Printf("Import %q as %s\n", imp.Path, imp.Alias)
+	}
+	if len(prog.Imports) > 0 {
+		fmt.Println()
+	}
+	for _, g := range prog.Globals {
+		printStmt(g, 0)
+	}
+	if len(prog.Globals) > 0 {
+		fmt.Println()
+	}
+	for _, e := range prog.Enums {
+		fmt.Printf("Enum %s { %s }\n", e.Name, strings.Join(e.Members, ", "))
+		fmt.Println()
+	}
+	for _, s := range prog.Structs {
+		fmt.Printf("Struct %s {\n", s.Name)
+		for _, f := range s.Fields {
+			fmt.Printf("  %s %s\n", f.Type, f.Name)
+		}
+		fmt.Println("}")
+		fmt.Println()
+	}
 	for _, fn := range prog.Functions {
 		printFunction(fn, 0)
 		fmt.Println()
@@ -199,7 +508,19 @@ func PrintProgram(prog *ast.Program) {
 
 func printFunction(fn *ast.FunctionDecl, indent int) {
 	ind := strings.Repeat("  ", indent)
-	fmt.Printf("%sFunction %s(", ind, fn.Name)
+	if fn.Exported {
+		fmt.Printf("%sExport ", ind)
+		ind = ""
+	}
+	fmt.Printf("%sFunction %s", ind, fn.Name)
+	if len(fn.TypeParams) > 0 {
+		params := make([]string, len(fn.TypeParams))
+		for i, tp := range fn.TypeParams {
+			params[i] = tp.Name + ": " + tp.Constraint
+		}
+		fmt.Printf("<%s>", strings.Join(params, ", "))
+	}
+	fmt.Print("(")
 	for i, p := range fn.Params {
 		if i > 0 {
 			fmt.Print(", ")
@@ -221,12 +542,25 @@ func printBlock(block *ast.BlockStmt, indent int) {
 	}
 }
 
+// elseIf возвращает If, если ветка else — ровно один вложенный If.
+func elseIf(s *ast.IfStmt) *ast.IfStmt {
+	if s.ElseBlock == nil || len(s.ElseBlock.Statements) != 1 {
+		return nil
+	}
+	next, _ := s.ElseBlock.Statements[0].(*ast.IfStmt)
+	return next
+}
+
 func printStmt(s ast.Stmt, indent int) {
 	ind := strings.Repeat("  ", indent)
 
 	switch st := s.(type) {
 	case *ast.VarDeclStmt:
-		fmt.Printf("%sVarDecl %s %s\n", ind, st.Type, st.Name)
+		if st.Const {
+			fmt.Printf("%sConstDecl %s %s\n", ind, st.Type, st.Name)
+		} else {
+			fmt.Printf("%sVarDecl %s %s\n", ind, st.Type, st.Name)
+		}
 		if st.Init != nil {
 			fmt.Printf("%s  Init:\n", ind)
 			printExpr(st.Init, indent+2)
@@ -249,7 +583,11 @@ func printStmt(s ast.Stmt, indent int) {
 		printBlock(st.Body, indent+2)
 
 	case *ast.AssignStmt:
-		fmt.Printf("%sAssign:\n", ind)
+		if st.Op != 0 {
+			fmt.Printf("%sAssign(%v):\n", ind, st.Op)
+		} else {
+			fmt.Printf("%sAssign:\n", ind)
+		}
 		fmt.Printf("%s  Target:\n", ind)
 		printExpr(st.Target, indent+2)
 		fmt.Printf("%s  Value:\n", ind)
@@ -272,6 +610,14 @@ func printStmt(s ast.Stmt, indent int) {
 		printExpr(st.Condition, indent+2)
 		fmt.Printf("%s  Then:\n", ind)
 		printBlock(st.ThenBlock, indent+2)
+		// цепочка else if печатается плоско, без вложенных If
+		for next := elseIf(st); next != nil; next = elseIf(st) {
+			st = next
+			fmt.Printf("%s  Else If:\n", ind)
+			printExpr(st.Condition, indent+2)
+			fmt.Printf("%s  Then:\n", ind)
+			printBlock(st.ThenBlock, indent+2)
+		}
 		if st.ElseBlock != nil {
 			fmt.Printf("%s  Else:\n", ind)
 			printBlock(st.ElseBlock, indent+2)
@@ -283,12 +629,47 @@ func printStmt(s ast.Stmt, indent int) {
 		printExpr(st.Condition, indent+2)
 		fmt.Printf("%s  Body:\n", ind)
 		printBlock(st.Body, indent+2)
+	case *ast.SwitchStmt:
+		fmt.Printf("%sSwitch:\n", ind)
+		fmt.Printf("%s  Subject:\n", ind)
+		printExpr(st.Subject, indent+2)
+		for _, c := range st.Cases {
+			fmt.Printf("%s  Case:\n", ind)
+			for _, v := range c.Values {
+				printExpr(v, indent+2)
+			}
+			printBlock(c.Body, indent+2)
+		}
+		if st.Default != nil {
+			fmt.Printf("%s  Default:\n", ind)
+			printBlock(st.Default, indent+2)
+		}
+
+	case *ast.TryStmt:
+		fmt.Printf("%sTry:\n", ind)
+		printBlock(st.Body, indent+1)
+		for _, c := range st.Catches {
+			if c.Name == "" {
+				fmt.Printf("%s  Catch:\n", ind)
+			} else {
+				fmt.Printf("%s  Catch %s %s:\n", ind, c.Type, c.Name)
+			}
+			printBlock(c.Body, indent+2)
+		}
+
+	case *ast.ThrowStmt:
+		fmt.Printf("%sThrow\n", ind)
+		printExpr(st.Value, indent+1)
+
 	case *ast.BreakStmt:
 		fmt.Printf("%sBreak\n", ind)
 
 	case *ast.ContinueStmt:
 		fmt.Printf("%sContinue\n", ind)
 
+	case *ast.BlockStmt:
+		printBlock(st, indent)
+
 	default:
 		fmt.Printf("%s<unknown stmt %T>\n", ind, st)
 	}
@@ -333,9 +714,37 @@ func printExpr(e ast.Expr, indent int) {
 		printExpr(ex.Index, indent+2)
 
 	case *ast.NewArrayExpr:
-		fmt.Printf("%sNewArray(%s):\n", ind, ex.ElementType.String())
-		fmt.Printf("%s  Length:\n", ind)
-		printExpr(ex.Length, indent+2)
+		fmt.Printf("%sNewArray(%s):\n", ind, ex.ArrayType().String())
+		for _, l := range ex.Lengths {
+			fmt.Printf("%s  Length:\n", ind)
+			printExpr(l, indent+2)
+		}
+
+	case *ast.NewStructExpr:
+		fmt.Printf("%sNewStruct(%s)\n", ind, ex.Name)
+
+	case *ast.NewCollectionExpr:
+		fmt.Printf("%sNewCollection(%s)\n", ind, ex.Type)
+
+	case *ast.FieldExpr:
+		fmt.Printf("%sField(%s):\n", ind, ex.Name)
+		printExpr(ex.Object, indent+1)
+
+	case *ast.CastExpr:
+		fmt.Printf("%sCast(%s):\n", ind, ex.Type)
+		printExpr(ex.Expr, indent+1)
+
+	case *ast.ArrayLiteralExpr:
+		fmt.Printf("%sArrayLiteral:\n", ind)
+		for _, el := range ex.Elements {
+			printExpr(el, indent+1)
+		}
+
+	case *ast.FuncLitExpr:
+		printFunction(ex.Decl, indent)
+		if len(ex.Captures) > 0 {
+			fmt.Printf("%s  Captures: %s\n", ind, strings.Join(ex.Captures, ", "))
+		}
 
 	default:
 		fmt.Printf("%s<unknown expr %T>\n", ind, ex)
@@ -353,7 +762,11 @@ func printInlineStmt(s ast.Stmt) {
 
 	case *ast.AssignStmt:
 		printInlineExpr(st.Target)
-		fmt.Print(" = ")
+		if st.Op != 0 {
+			fmt.Printf(" %v= ", st.Op)
+		} else {
+			fmt.Print(" = ")
+		}
 		printInlineExpr(st.Value)
 
 	case *ast.ExprStmt:
@@ -400,10 +813,50 @@ func printInlineExpr(e ast.Expr) {
 		fmt.Print("]")
 
 	case *ast.NewArrayExpr:
-		fmt.Printf("new %s[", ex.ElementType.String())
-		printInlineExpr(ex.Length)
+		fmt.Printf("new %s", ex.ElementType.String())
+		for i := 0; i < ex.Dims; i++ {
+			fmt.Print("[")
+			if i < len(ex.Lengths) {
+				printInlineExpr(ex.Lengths[i])
+			}
+			fmt.Print("]")
+		}
+
+	case *ast.NewStructExpr:
+		fmt.Printf("new %s", ex.Name)
+
+	case *ast.NewCollectionExpr:
+		fmt.Printf("new %s", ex.Type)
+
+	case *ast.FieldExpr:
+		printInlineExpr(ex.Object)
+		fmt.Printf(".%s", ex.Name)
+
+	case *ast.CastExpr:
+		fmt.Printf("%s(", ex.Type)
+		printInlineExpr(ex.Expr)
+		fmt.Print(")")
+
+	case *ast.ArrayLiteralExpr:
+		fmt.Print("[")
+		for i, el := range ex.Elements {
+			if i > 0 {
+				fmt.Print(", ")
+			}
+			printInlineExpr(el)
+		}
 		fmt.Print("]")
 
+	case *ast.FuncLitExpr:
+		fmt.Print("function(")
+		for i, p := range ex.Decl.Params {
+			if i > 0 {
+				fmt.Print(", ")
+			}
+			fmt.Printf("%s %s", p.Type, p.Name)
+		}
+		fmt.Printf(") %s {...}", ex.Decl.ReturnType)
+
 	default:
 		fmt.Printf("<expr %T>", ex)
 	}
@@ -3,6 +3,7 @@ package parser
 import (
 	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
 	"github.com/ChernykhITMO/compiler/internal/frontend/token"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
 )
 
 func (p *Parser) parseStatement() ast.Stmt {
@@ -15,9 +16,20 @@ func (p *Parser) parseStatement() ast.Stmt {
 	if p.match(token.TokenFor) {
 		return p.parseForStmt()
 	}
+	if p.match(token.TokenSwitch) {
+		return p.parseSwitchStmt()
+	}
 	if p.match(token.TokenReturn) {
 		return p.parseReturnStmt()
 	}
+	if p.match(token.TokenTry) {
+		return p.parseTryStmt()
+	}
+	if p.match(token.TokenThrow) {
+		val := p.parseExpression()
+		p.match(token.TokenNewline)
+		return &ast.ThrowStmt{Value: val}
+	}
 	if p.match(token.TokenBreak) {
 		p.match(token.TokenNewline)
 		return &ast.BreakStmt{}
@@ -27,9 +39,12 @@ func (p *Parser) parseStatement() ast.Stmt {
 		return &ast.ContinueStmt{}
 	}
 
-	if p.check(token.TokenInt) || p.check(token.TokenFloat) ||
+	// int(x) в начале строки — преобразование, а не объявление
+	if (p.check(token.TokenInt) || p.check(token.TokenFloat) ||
 		p.check(token.TokenString) || p.check(token.TokenBool) ||
-		p.check(token.TokenChar) || p.check(token.TokenVoid) {
+		p.check(token.TokenChar) || p.check(token.TokenVoid) ||
+		p.isStructTypeStart()) && p.peek(1) != token.TokenLeftParen ||
+		p.check(token.TokenFunction) {
 		return p.parseVarDeclOrExprStmt()
 	}
 
@@ -40,7 +55,7 @@ func (p *Parser) parseStatement() ast.Stmt {
 		p.match(token.TokenNewline)
 
 		switch expr.(type) {
-		case *ast.IdentExpr, *ast.IndexExpr:
+		case *ast.IdentExpr, *ast.IndexExpr, *ast.FieldExpr:
 			return &ast.AssignStmt{
 				Target: expr,
 				Value:  value,
@@ -50,10 +65,64 @@ func (p *Parser) parseStatement() ast.Stmt {
 		}
 	}
 
+	if p.check(token.TokenIncrement) || p.check(token.TokenDecrement) {
+		op := token.TokenPlus
+		if p.advance().Type == token.TokenDecrement {
+			op = token.TokenMinus
+		}
+		p.match(token.TokenNewline)
+
+		one := &ast.LiteralExpr{Lexeme: "1", Token: token.TokenNumber, Type: types.Type{Kind: types.TypeInt}}
+		return compoundAssign(expr, op, one)
+	}
+
+	if op, ok := compoundOps[p.peek(0)]; ok {
+		p.advance()
+		value := p.parseExpression()
+		p.match(token.TokenNewline)
+
+		return compoundAssign(expr, op, value)
+	}
+
 	p.match(token.TokenNewline)
 	return &ast.ExprStmt{Expr: expr}
 }
 
+// compoundOps — операция, которую выполняет составное присваивание.
+var compoundOps = map[token.TokenType]token.TokenType{
+	token.TokenPlusAssign:     token.TokenPlus,
+	token.TokenMinusAssign:    token.TokenMinus,
+	token.TokenMultiplyAssign: token.TokenMultiply,
+	token.TokenDivideAssign:   token.TokenDivide,
+	token.TokenModuloAssign:   token.TokenModulo,
+
+	token.TokenBitAndAssign:     token.TokenBitAnd,
+	token.TokenBitOrAssign:      token.TokenBitOr,
+	token.TokenShiftLeftAssign:  token.TokenShiftLeft,
+	token.TokenShiftRightAssign: token.TokenShiftRight,
+}
+
+// compoundAssign строит target op= value. Переменную можно прочитать
+// повторно без побочных эффектов, поэтому x += v становится x = x + v —
+// так оптимизатор видит обычное присваивание.
+func compoundAssign(target ast.Expr, op token.TokenType, value ast.Expr) ast.Stmt {
+	switch t := target.(type) {
+	case *ast.IdentExpr:
+		return &ast.AssignStmt{
+			Target: target,
+			Value: &ast.BinaryExpr{
+				Left:  &ast.IdentExpr{Name: t.Name},
+				Op:    op,
+				Right: value,
+			},
+		}
+	case *ast.IndexExpr, *ast.FieldExpr:
+		return &ast.AssignStmt{Target: target, Op: op, Value: value}
+	default:
+		panic("invalid assignment target")
+	}
+}
+
 func (p *Parser) parseVarDeclOrExprStmt() ast.Stmt {
 	typeName := p.parseTypeName()
 	nameTok := p.consume(token.TokenIdentifier, "expected variable name")
@@ -81,6 +150,26 @@ func (p *Parser) parseReturnStmt() ast.Stmt {
 	return &ast.ReturnStmt{Value: val}
 }
 
+// parseTryStmt: try { ... } catch (string e) { ... } catch { ... }.
+func (p *Parser) parseTryStmt() ast.Stmt {
+	s := &ast.TryStmt{Body: p.parseBlock()}
+
+	for p.match(token.TokenCatch) {
+		c := &ast.CatchClause{}
+		if p.match(token.TokenLeftParen) {
+			c.Type = p.parseTypeName()
+			c.Name = p.consume(token.TokenIdentifier, "expected variable name in catch").Text
+			p.consume(token.TokenRightParen, "expected ')' after catch variable")
+		}
+		c.Body = p.parseBlock()
+		s.Catches = append(s.Catches, c)
+	}
+	if len(s.Catches) == 0 {
+		panic("try without catch")
+	}
+	return s
+}
+
 func (p *Parser) parseIfStmt() ast.Stmt {
 	hasParen := p.match(token.TokenLeftParen)
 	cond := p.parseExpression()
@@ -88,11 +177,16 @@ func (p *Parser) parseIfStmt() ast.Stmt {
 		p.consume(token.TokenRightParen, "expected ')' after if condition")
 	}
 
-	thenBlock := p.parseBlock()
+	thenBlock := p.parseBody()
 
+	// else if (...) — вложенный IfStmt, единственный оператор ветки else
 	var elseBlock *ast.BlockStmt
 	if p.match(token.TokenElse) {
-		elseBlock = p.parseBlock()
+		if p.match(token.TokenIf) {
+			elseBlock = &ast.BlockStmt{Statements: []ast.Stmt{p.parseIfStmt()}}
+		} else {
+			elseBlock = p.parseBody()
+		}
 	}
 
 	return &ast.IfStmt{
@@ -102,6 +196,19 @@ func (p *Parser) parseIfStmt() ast.Stmt {
 	}
 }
 
+// parseBody — тело if/else/while/for: блок или один оператор,
+// который оборачивается в блок.
+func (p *Parser) parseBody() *ast.BlockStmt {
+	if p.check(token.TokenLeftBrace) {
+		return p.parseBlock()
+	}
+	stmt := p.parseStatement()
+	if _, ok := stmt.(*ast.VarDeclStmt); ok {
+		panic("declaration is not allowed as a single-statement body")
+	}
+	return &ast.BlockStmt{Statements: []ast.Stmt{stmt}}
+}
+
 func (p *Parser) parseWhileStmt() ast.Stmt {
 	hasParen := p.match(token.TokenLeftParen)
 	cond := p.parseExpression()
@@ -109,7 +216,7 @@ func (p *Parser) parseWhileStmt() ast.Stmt {
 		p.consume(token.TokenRightParen, "expected ')' after while condition")
 	}
 
-	body := p.parseBlock()
+	body := p.parseBody()
 	return &ast.WhileStmt{
 		Condition: cond,
 		Body:      body,
@@ -140,7 +247,7 @@ func (p *Parser) parseForStmt() ast.Stmt {
 		p.consume(token.TokenRightParen, "expected ')' after for clauses")
 	}
 
-	body := p.parseBlock()
+	body := p.parseBody()
 
 	return &ast.ForStmt{
 		Init:      init,
@@ -149,3 +256,61 @@ func (p *Parser) parseForStmt() ast.Stmt {
 		Body:      body,
 	}
 }
+
+func (p *Parser) parseSwitchStmt() ast.Stmt {
+	hasParen := p.match(token.TokenLeftParen)
+	subject := p.parseExpression()
+	if hasParen {
+		p.consume(token.TokenRightParen, "expected ')' after switch subject")
+	}
+
+	p.consume(token.TokenLeftBrace, "expected '{' to start switch")
+	sw := &ast.SwitchStmt{Subject: subject}
+
+	for !p.check(token.TokenRightBrace) && !p.isAtEnd() {
+		if p.match(token.TokenNewline) {
+			continue
+		}
+
+		if p.match(token.TokenDefault) {
+			if sw.Default != nil {
+				panic("multiple default clauses in switch")
+			}
+			p.consume(token.TokenColon, "expected ':' after default")
+			sw.Default = p.parseCaseBody()
+			continue
+		}
+
+		p.consume(token.TokenCase, "expected 'case' or 'default' in switch")
+		c := &ast.SwitchCase{}
+		for {
+			c.Values = append(c.Values, p.parseExpression())
+			if !p.match(token.TokenComma) {
+				break
+			}
+		}
+		p.consume(token.TokenColon, "expected ':' after case values")
+		c.Body = p.parseCaseBody()
+		sw.Cases = append(sw.Cases, c)
+	}
+
+	p.consume(token.TokenRightBrace, "expected '}' to end switch")
+	p.match(token.TokenNewline)
+
+	return sw
+}
+
+// parseCaseBody читает операторы до следующего case, default или '}'.
+func (p *Parser) parseCaseBody() *ast.BlockStmt {
+	body := &ast.BlockStmt{}
+	for !p.check(token.TokenCase) && !p.check(token.TokenDefault) &&
+		!p.check(token.TokenRightBrace) && !p.isAtEnd() {
+		if p.match(token.TokenNewline) {
+			continue
+		}
+		if stmt := p.parseStatement(); stmt != nil {
+			body.Statements = append(body.Statements, stmt)
+		}
+	}
+	return body
+}
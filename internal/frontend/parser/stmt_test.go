@@ -0,0 +1,105 @@
+package parser_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+)
+
+// body разбирает src как тело функции f.
+func body(t *testing.T, src string) []ast.Stmt {
+	t.Helper()
+	prog := parser.NewParser(lexer.NewLexer("function f(int x) int {\n" + src + "\n}\n").Tokenize()).ParseProgram()
+	return prog.Functions[0].Body.Statements
+}
+
+// parsePanic возвращает текст паники парсера или "".
+func parsePanic(src string) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	parser.NewParser(lexer.NewLexer(src).Tokenize()).ParseProgram()
+	return ""
+}
+
+func TestElseIfChain(t *testing.T) {
+	stmts := body(t, `
+    if (x < 0) {
+        return 1
+    } else if (x == 0) {
+        return 2
+    } else if (x == 1) return 3
+    else {
+        return 4
+    }
+    return 5
+`)
+	if len(stmts) != 2 {
+		t.Fatalf("%d statements, want if and return", len(stmts))
+	}
+
+	depth := 0
+	for s := stmts[0]; ; {
+		ifs, ok := s.(*ast.IfStmt)
+		if !ok {
+			t.Fatalf("depth %d: %T, want IfStmt", depth, s)
+		}
+		if len(ifs.ThenBlock.Statements) != 1 {
+			t.Fatalf("depth %d: then has %d statements", depth, len(ifs.ThenBlock.Statements))
+		}
+		depth++
+		if len(ifs.ElseBlock.Statements) != 1 {
+			t.Fatalf("depth %d: else has %d statements, want one", depth, len(ifs.ElseBlock.Statements))
+		}
+		s = ifs.ElseBlock.Statements[0]
+		if _, ok := s.(*ast.ReturnStmt); ok {
+			break
+		}
+	}
+	if depth != 3 {
+		t.Fatalf("chain depth %d, want 3 nested ifs", depth)
+	}
+}
+
+func TestSingleStatementBodies(t *testing.T) {
+	stmts := body(t, `
+    int max = 0
+    if (x > max) max = x
+    while (x > 9) x = x / 10
+    for (int i = 0; i < 3; i++) max += i
+    return max
+`)
+	for _, s := range stmts[1:4] {
+		var b *ast.BlockStmt
+		switch st := s.(type) {
+		case *ast.IfStmt:
+			b = st.ThenBlock
+		case *ast.WhileStmt:
+			b = st.Body
+		case *ast.ForStmt:
+			b = st.Body
+		}
+		if b == nil || len(b.Statements) != 1 {
+			t.Fatalf("%T: body is not a single-statement block", s)
+		}
+		if _, ok := b.Statements[0].(*ast.AssignStmt); !ok {
+			t.Fatalf("%T: body holds %T, want assignment", s, b.Statements[0])
+		}
+	}
+	if _, ok := stmts[4].(*ast.ReturnStmt); !ok {
+		t.Fatalf("last statement %T, want return after the loop", stmts[4])
+	}
+}
+
+func TestDeclarationAsBody(t *testing.T) {
+	msg := parsePanic("function f(int x) void {\n    if (x > 0) int y = 1\n}\n")
+	if !strings.Contains(msg, "declaration is not allowed as a single-statement body") {
+		t.Fatalf("panic %q, want declaration error", msg)
+	}
+}
@@ -0,0 +1,93 @@
+package semantics_test
+
+import "testing"
+
+func TestGenericConstraintErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		typ  string
+		msg  string
+	}{
+		{"bool is not ordered", `
+function max<T: ordered>(T a, T b) T {
+    if (a > b) return a
+    return b
+}
+
+function test() bool {
+    return max(true, false)
+}
+`, "TypeArgument", "function 'max': bool does not satisfy ordered (type parameter 'T')"},
+		{"array is not comparable", `
+function eq<T: comparable>(T a, T b) bool {
+    return a == b
+}
+
+function test() bool {
+    int[] a = [1]
+    return eq(a, a)
+}
+`, "TypeArgument", "function 'eq': int[] does not satisfy comparable (type parameter 'T')"},
+		{"enum is not ordered", `
+enum Color { Red, Green }
+
+function max<T: ordered>(T a, T b) T {
+    if (a > b) return a
+    return b
+}
+
+function test() Color {
+    return max(Color.Red, Color.Green)
+}
+`, "TypeArgument", "function 'max': Color does not satisfy ordered (type parameter 'T')"},
+		{"arithmetic needs number", `
+function sum<T: ordered>(T a, T b) T {
+    return a + b
+}
+`, "TypeMismatch", "operator '+' is not defined for T and T"},
+		{"comparison needs ordered", `
+function less<T: comparable>(T a, T b) bool {
+    return a < b
+}
+`, "TypeMismatch", "operator '<' is not defined for T and T"},
+		{"mismatched arguments", `
+function max<T: ordered>(T a, T b) T {
+    if (a > b) return a
+    return b
+}
+
+function test() string {
+    return max("a", 1)
+}
+`, "TypeArgument", "function 'max', argument 'b'"},
+		{"not inferred", `
+function make<T>(int n) T[] {
+    return new T[n]
+}
+
+function test() void {
+    int[] a = make(3)
+}
+`, "TypeArgument", "cannot infer type parameter 'T' of function 'make'"},
+		{"unknown constraint", `
+function f<T: sortable>(T a) T {
+    return a
+}
+`, "UnknownType", "unknown constraint 'sortable' of type parameter 'T'"},
+		{"generic as value", `
+function id<T>(T a) T {
+    return a
+}
+
+function test() void {
+    function(int) int f = id
+}
+`, "TypeArgument", "generic function 'id' cannot be used as a value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, tt.typ, tt.msg)
+		})
+	}
+}
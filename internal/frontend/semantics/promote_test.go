@@ -0,0 +1,98 @@
+package semantics_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+	"github.com/ChernykhITMO/compiler/internal/frontend/semantics"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+func TestIntPromotedToFloat(t *testing.T) {
+	prog := parser.NewParser(lexer.NewLexer(`
+function f(int i, float x) float {
+    return i + x
+}
+`).Tokenize()).ParseProgram()
+	if errs := semantics.NewChecker().Check(prog); len(errs) > 0 {
+		t.Fatalf("check: [%s] %s", errs[0].Type, errs[0].Message)
+	}
+
+	bin := prog.Functions[0].Body.Statements[0].(*ast.ReturnStmt).Value.(*ast.BinaryExpr)
+	cast, ok := bin.Left.(*ast.CastExpr)
+	if !ok || cast.Type.Kind != types.TypeFloat {
+		t.Fatalf("left operand %T, want cast to float", bin.Left)
+	}
+	if _, ok := bin.Right.(*ast.IdentExpr); !ok {
+		t.Fatalf("right operand %T, want x unchanged", bin.Right)
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		msg  string
+	}{
+		{"float to int variable", `
+function test() void {
+    int x = 2.5
+}
+`, "variable 'x': cannot use float as int"},
+		{"float to char", `
+function test() char {
+    return char(1.5)
+}
+`, "cannot convert float to char"},
+		{"float argument", `
+function g(int n) int {
+    return n
+}
+
+function test() int {
+    return g(1.0)
+}
+`, "argument 'n' of function 'g': cannot use float as int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, "TypeMismatch", tt.msg)
+		})
+	}
+}
+
+func TestArrayLiteralPromotion(t *testing.T) {
+	prog := parser.NewParser(lexer.NewLexer(`
+function f() float {
+    float[] a = [1, 2.5]
+    float[] b = [1, 2]
+    return a[0] + b[1]
+}
+`).Tokenize()).ParseProgram()
+	if errs := semantics.NewChecker().Check(prog); len(errs) > 0 {
+		t.Fatalf("check: [%s] %s", errs[0].Type, errs[0].Message)
+	}
+
+	for _, stmt := range prog.Functions[0].Body.Statements[:2] {
+		decl := stmt.(*ast.VarDeclStmt)
+		for i, el := range decl.Init.(*ast.ArrayLiteralExpr).Elements {
+			if lit, ok := el.(*ast.LiteralExpr); ok && lit.Type.Kind == types.TypeFloat {
+				continue
+			}
+			if cast, ok := el.(*ast.CastExpr); !ok || cast.Type.Kind != types.TypeFloat {
+				t.Fatalf("%s[%d] = %T, want float element", decl.Name, i, el)
+			}
+		}
+	}
+}
+
+func TestIntArrayVariableIsNotFloatArray(t *testing.T) {
+	expectError(t, `
+function test() void {
+    int[] a = [1, 2]
+    float[] b = a
+}
+`, "TypeMismatch", "variable 'b': cannot use int[] as float[]")
+}
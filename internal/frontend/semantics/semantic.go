@@ -2,17 +2,89 @@ package semantics
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 
+	"github.com/ChernykhITMO/compiler/internal/backend/native"
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
 	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/token"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
 )
 
 const (
 	duplicateVar       = "duplicateVariable"
 	undeclaredVariable = "UndeclaredVariable"
+	builtinArgCount    = "BuiltinArgCount"
+	argCount           = "ArgCount"
+	typeMismatch       = "TypeMismatch"
+	duplicateStruct    = "DuplicateStruct"
+	duplicateField     = "DuplicateField"
+	unknownType        = "UnknownType"
+	unknownField       = "UnknownField"
+	constAssignment    = "ConstAssignment"
+	constInit          = "ConstInit"
+	caseValue          = "CaseValue"
+	duplicateCase      = "DuplicateCase"
+	typeArgument       = "TypeArgument"
+	catchClause        = "CatchClause"
+	importName         = "ImportName"
+	nullValue          = "NullValue"
+	duplicateEnum      = "DuplicateEnum"
+	enumName           = "EnumName"
 )
 
-var builtins = map[string]struct{}{
-	"print": {},
+// builtins: имя -> число аргументов
+var builtins = map[string]int{
+	"print":      1,
+	"len":        1,
+	"str":        1,
+	"parseInt":   1,
+	"parseFloat": 1,
+}
+
+var opNames = map[token.TokenType]string{
+	token.TokenPlus:         "+",
+	token.TokenMinus:        "-",
+	token.TokenMultiply:     "*",
+	token.TokenDivide:       "/",
+	token.TokenModulo:       "%",
+	token.TokenPower:        "^",
+	token.TokenEqual:        "==",
+	token.TokenNotEqual:     "!=",
+	token.TokenLess:         "<",
+	token.TokenLessEqual:    "<=",
+	token.TokenGreater:      ">",
+	token.TokenGreaterEqual: ">=",
+	token.TokenAnd:          "&&",
+	token.TokenOr:           "||",
+	token.TokenNot:          "!",
+	token.TokenBitAnd:       "&",
+	token.TokenBitOr:        "|",
+	token.TokenXor:          "xor",
+	token.TokenBitNot:       "~",
+	token.TokenShiftLeft:    "<<",
+	token.TokenShiftRight:   ">>",
+}
+
+// constraints: ограничение параметра типа -> допустимые типы аргумента.
+// Ограничения вложены: number ⊂ ordered ⊂ comparable ⊂ any.
+var constraints = map[string][]types.BasicType{
+	"number":     {types.TypeInt, types.TypeFloat},
+	"ordered":    {types.TypeInt, types.TypeFloat, types.TypeChar, types.TypeString},
+	"comparable": {types.TypeInt, types.TypeFloat, types.TypeChar, types.TypeString, types.TypeBool, types.TypeEnum},
+	"any":        nil,
+}
+
+var constraintRank = map[string]int{"number": 0, "ordered": 1, "comparable": 2, "any": 3}
+
+// bitwiseOps определены только для int.
+var bitwiseOps = map[token.TokenType]bool{
+	token.TokenBitAnd:     true,
+	token.TokenBitOr:      true,
+	token.TokenXor:        true,
+	token.TokenShiftLeft:  true,
+	token.TokenShiftRight: true,
 }
 
 type SemanticError struct {
@@ -20,15 +92,41 @@ type SemanticError struct {
 	Message string
 }
 
+// Checker проверяет области видимости и типы. Тип TypeInvalid означает
+// «неизвестно» — по нему ошибки не выдаются, чтобы не плодить каскад.
 type Checker struct {
-	functions map[string]struct{}
+	functions map[string]*ast.FunctionDecl
+	structs   map[string]*ast.StructDecl
+	enums     map[string]*ast.EnumDecl
 	errors    []SemanticError
-	scopes    []map[string]struct{}
+	scopes    []map[string]*variable // scopes[0] — глобальные переменные
+	consts    map[string]ast.Expr    // инициализаторы глобальных const
+	fn        *ast.FunctionDecl
+	lits      []funcLit // анонимные функции, внутри которых идет проверка
+	imports   map[string]struct{}
+}
+
+// variable — объявление в области видимости. captured указывает на флаг
+// Captured в AST: его выставляет вложенная функция, использующая переменную.
+type variable struct {
+	typ      types.Type
+	captured *bool
+}
+
+// funcLit — проверяемая анонимная функция; ее области видимости
+// начинаются с scopes[base].
+type funcLit struct {
+	base int
+	expr *ast.FuncLitExpr
 }
 
 func NewChecker() *Checker {
 	return &Checker{
-		functions: make(map[string]struct{}),
+		functions: make(map[string]*ast.FunctionDecl),
+		structs:   make(map[string]*ast.StructDecl),
+		enums:     make(map[string]*ast.EnumDecl),
+		consts:    make(map[string]ast.Expr),
+		imports:   make(map[string]struct{}),
 		errors:    make([]SemanticError, 0),
 	}
 }
@@ -36,28 +134,149 @@ func NewChecker() *Checker {
 func (c *Checker) Check(program *ast.Program) []SemanticError {
 	c.errors = []SemanticError{}
 
+	for _, imp := range program.Imports {
+		c.imports[imp.Alias] = struct{}{}
+	}
+
+	for _, s := range program.Structs {
+		if _, ok := c.structs[s.Name]; ok {
+			c.addError(duplicateStruct,
+				fmt.Sprintf("struct '%s' is already defined", s.Name))
+			continue
+		}
+		c.structs[s.Name] = s
+	}
+	for _, e := range program.Enums {
+		_, isStruct := c.structs[e.Name]
+		if _, ok := c.enums[e.Name]; ok || isStruct {
+			c.addError(duplicateEnum,
+				fmt.Sprintf("type '%s' is already defined", e.Name))
+			continue
+		}
+		c.enums[e.Name] = e
+	}
+	for _, s := range program.Structs {
+		c.checkStruct(s)
+	}
+
 	for _, fn := range program.Functions {
-		c.functions[fn.Name] = struct{}{}
+		c.functions[fn.Name] = fn
+	}
+
+	// глобальные видны во всех функциях, а в инициализаторах —
+	// только объявленные выше
+	c.pushScope()
+	defer c.popScope()
+
+	for _, g := range program.Globals {
+		c.checkGlobal(g)
 	}
 
 	for _, fn := range program.Functions {
-		c.checkFunction(fn)
+		if fn.Extern == "" {
+			c.checkFunction(fn)
+		}
 	}
 
 	return c.errors
 }
 
+func (c *Checker) checkStruct(s *ast.StructDecl) {
+	fields := make(map[string]struct{}, len(s.Fields))
+	for _, f := range s.Fields {
+		if _, ok := fields[f.Name]; ok {
+			c.addError(duplicateField,
+				fmt.Sprintf("field '%s' is already defined in struct '%s'", f.Name, s.Name))
+		}
+		fields[f.Name] = struct{}{}
+		c.checkTypeKnown(f.Type)
+	}
+}
+
+func (c *Checker) checkGlobal(g *ast.VarDeclStmt) {
+	c.checkStatement(g)
+	if !g.Const {
+		return
+	}
+	if !c.isConstExpr(g.Init) {
+		c.addError(constInit,
+			fmt.Sprintf("const '%s' must be initialized with a constant expression", g.Name))
+	}
+	c.consts[g.Name] = g.Init
+}
+
+// isConstExpr: литералы, другие константы и операторы над ними.
+func (c *Checker) isConstExpr(e ast.Expr) bool {
+	switch ex := e.(type) {
+	case *ast.LiteralExpr:
+		return true
+	case *ast.IdentExpr:
+		return c.isConst(ex.Name)
+	case *ast.UnaryExpr:
+		return c.isConstExpr(ex.Expr)
+	case *ast.CastExpr:
+		return c.isConstExpr(ex.Expr)
+	case *ast.BinaryExpr:
+		return c.isConstExpr(ex.Left) && c.isConstExpr(ex.Right)
+	}
+	return false
+}
+
+// isConst: имя разрешается в глобальную константу, а не в локал с тем же именем.
+func (c *Checker) isConst(name string) bool {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if _, ok := c.scopes[i][name]; ok {
+			_, isConst := c.consts[name]
+			return i == 0 && isConst
+		}
+	}
+	return false
+}
+
 func (c *Checker) checkFunction(fn *ast.FunctionDecl) {
 	c.pushScope()
 	defer c.popScope()
 
-	for _, param := range fn.Params {
-		c.declareVar(param.Name)
+	c.fn = fn
+	c.checkTypeParams(fn)
+	c.checkTypeKnown(fn.ReturnType)
+	for i, param := range fn.Params {
+		c.checkTypeKnown(param.Type)
+		c.declareVar(param.Name, param.Type, &fn.Params[i].Captured)
 	}
 
 	c.checkBlock(fn.Body)
 }
 
+func (c *Checker) checkTypeParams(fn *ast.FunctionDecl) {
+	seen := make(map[string]struct{}, len(fn.TypeParams))
+	for _, tp := range fn.TypeParams {
+		if _, ok := seen[tp.Name]; ok {
+			c.addError(typeArgument,
+				fmt.Sprintf("type parameter '%s' is already defined in function '%s'", tp.Name, fn.Name))
+		}
+		seen[tp.Name] = struct{}{}
+		if _, ok := constraints[tp.Constraint]; !ok {
+			c.addError(unknownType,
+				fmt.Sprintf("unknown constraint '%s' of type parameter '%s'", tp.Constraint, tp.Name))
+		}
+	}
+}
+
+// checkFuncLit проверяет тело анонимной функции как отдельную функцию,
+// но с доступом к локалам объемлющих.
+func (c *Checker) checkFuncLit(e *ast.FuncLitExpr) types.Type {
+	outer := c.fn
+	c.lits = append(c.lits, funcLit{base: len(c.scopes), expr: e})
+	defer func() {
+		c.fn = outer
+		c.lits = c.lits[:len(c.lits)-1]
+	}()
+
+	c.checkFunction(e.Decl)
+	return funcType(e.Decl)
+}
+
 func (c *Checker) checkBlock(block *ast.BlockStmt) {
 	if block == nil {
 		return
@@ -73,86 +292,1057 @@ func (c *Checker) checkBlock(block *ast.BlockStmt) {
 func (c *Checker) checkStatement(stmt ast.Stmt) {
 	switch s := stmt.(type) {
 	case *ast.VarDeclStmt:
-		c.declareVar(s.Name)
+		c.checkTypeKnown(s.Type)
 		if s.Init != nil {
-			c.checkExpression(s.Init)
+			t := widenLiteral(s.Init, c.checkExpression(s.Init), s.Type)
+			c.expectAssignable(s.Type, t, fmt.Sprintf("variable '%s'", s.Name))
+			s.Init = promote(s.Init, t, s.Type)
 		}
+		c.declareVar(s.Name, s.Type, &s.Captured)
 
 	case *ast.AssignStmt:
-		c.checkExpression(s.Target)
-		c.checkExpression(s.Value)
+		if id, ok := s.Target.(*ast.IdentExpr); ok && c.isConst(id.Name) {
+			c.addError(constAssignment,
+				fmt.Sprintf("cannot assign to const '%s'", id.Name))
+		}
+		target := c.checkTarget(s.Target)
+		value := widenLiteral(s.Value, c.checkExpression(s.Value), target)
+		s.Value = promote(s.Value, value, target)
+		value = arithmeticType(value, target)
+		if s.Op != 0 {
+			value = c.checkBinary(s.Op, arithmeticType(target, value), value)
+		}
+		c.expectAssignable(target, value, "assignment")
 
 	case *ast.ExprStmt:
 		c.checkExpression(s.Expr)
 
 	case *ast.ReturnStmt:
 		if s.Value != nil {
-			c.checkExpression(s.Value)
+			t := widenLiteral(s.Value, c.checkExpression(s.Value), c.fn.ReturnType)
+			if c.fn.ReturnType.Kind != types.TypeVoid {
+				c.expectAssignable(c.fn.ReturnType, t,
+					fmt.Sprintf("return in function '%s'", c.fn.Name))
+				s.Value = promote(s.Value, t, c.fn.ReturnType)
+			}
 		}
 
 	case *ast.IfStmt:
-		c.checkExpression(s.Condition)
+		c.expectCondition(s.Condition, "if")
 		c.checkBlock(s.ThenBlock)
 		if s.ElseBlock != nil {
 			c.checkBlock(s.ElseBlock)
 		}
 
 	case *ast.WhileStmt:
-		c.checkExpression(s.Condition)
+		c.expectCondition(s.Condition, "while")
 		c.checkBlock(s.Body)
 
+	case *ast.SwitchStmt:
+		c.checkSwitch(s)
+
+	case *ast.TryStmt:
+		c.checkBlock(s.Body)
+		c.checkCatches(s)
+
+	case *ast.ThrowStmt:
+		if t := c.checkExpression(s.Value); t.Kind == types.TypeVoid {
+			c.addError(typeMismatch, "throw: expression has no value")
+		}
+
 	case *ast.ForStmt:
+		// переменная из инициализатора живет только внутри for
+		c.pushScope()
+		defer c.popScope()
+
 		if s.Init != nil {
 			c.checkStatement(s.Init)
 		}
 		if s.Condition != nil {
-			c.checkExpression(s.Condition)
+			c.expectCondition(s.Condition, "for")
 		}
 		if s.Increment != nil {
 			c.checkStatement(s.Increment)
 		}
 		c.checkBlock(s.Body)
+
+	case *ast.BlockStmt:
+		c.checkBlock(s)
 	}
 }
 
-func (c *Checker) checkExpression(expr ast.Expr) {
+// checkSwitch: subject — int, char или string, значения case — константы
+// того же типа без повторов.
+func (c *Checker) checkSwitch(s *ast.SwitchStmt) {
+	subject := c.checkExpression(s.Subject)
+	switch subject.Kind {
+	case types.TypeInvalid, types.TypeInt, types.TypeChar, types.TypeString, types.TypeEnum:
+	default:
+		c.addError(typeMismatch,
+			fmt.Sprintf("switch: cannot switch on %s", subject))
+		subject = types.Type{}
+	}
+
+	seen := make(map[string]struct{})
+	for _, cs := range s.Cases {
+		for _, v := range cs.Values {
+			got := c.checkExpression(v)
+			if subject.Kind != types.TypeInvalid {
+				c.expectType(subject, got, "case value")
+			}
+			key, ok := c.caseKey(v, got)
+			if !ok {
+				c.addError(caseValue, "case value must be a constant expression")
+				continue
+			}
+			if _, dup := seen[key]; dup {
+				c.addError(duplicateCase,
+					fmt.Sprintf("duplicate case %s in switch", key))
+			}
+			seen[key] = struct{}{}
+		}
+		c.checkBlock(cs.Body)
+	}
+	if s.Default != nil {
+		c.checkBlock(s.Default)
+	}
+}
+
+// checkCatches: тип ветки — int, float, bool, char, string или структура,
+// их VM различает во время выполнения. Ветка без типа — последняя.
+func (c *Checker) checkCatches(s *ast.TryStmt) {
+	seen := make(map[string]struct{})
+	for i, cc := range s.Catches {
+		if cc.Name == "" {
+			if i != len(s.Catches)-1 {
+				c.addError(catchClause, "catch without type must be the last catch clause")
+			}
+		} else {
+			switch cc.Type.Kind {
+			case types.TypeInt, types.TypeFloat, types.TypeBool, types.TypeChar, types.TypeString:
+			case types.TypeStruct:
+				c.checkTypeKnown(cc.Type)
+			default:
+				c.addError(catchClause,
+					fmt.Sprintf("cannot catch by type %s", cc.Type))
+			}
+			if _, dup := seen[cc.Type.String()]; dup {
+				c.addError(catchClause,
+					fmt.Sprintf("duplicate catch clause for %s", cc.Type))
+			}
+			seen[cc.Type.String()] = struct{}{}
+		}
+
+		c.pushScope()
+		if cc.Name != "" {
+			c.declareVar(cc.Name, cc.Type, &cc.Captured)
+		}
+		c.checkBlock(cc.Body)
+		c.popScope()
+	}
+}
+
+// caseKey — запись значения case для поиска повторов. Значение
+// вычисляется так же, как при свертке констант, поэтому case 1+1
+// повторяет case 2. false — выражение не сворачивается в константу.
+func (c *Checker) caseKey(e ast.Expr, t types.Type) (string, bool) {
+	v, ok := c.constValue(e)
+	if !ok {
+		return "", false
+	}
+	switch v.Kind {
+	case bytecode.ValInt:
+		if t.Kind != types.TypeEnum {
+			return strconv.FormatInt(v.I, 10), true
+		}
+		if decl, ok := c.enums[t.Name]; ok && v.I >= 0 && v.I < int64(len(decl.Members)) {
+			return t.Name + "." + decl.Members[v.I], true
+		}
+	case bytecode.ValChar:
+		return strconv.Quote(string(v.C)), true
+	case bytecode.ValString:
+		return strconv.Quote(v.S), true
+	}
+	return "", false
+}
+
+// constValue вычисляет константное выражение над int, char и string:
+// литералы, const и операторы над ними. Арифметика — та же, что в VM
+// и оптимизаторе; переполнение и деление на ноль не сворачиваются.
+func (c *Checker) constValue(e ast.Expr) (bytecode.Value, bool) {
+	switch ex := e.(type) {
+	case *ast.LiteralExpr:
+		switch ex.Type.Kind {
+		case types.TypeInt, types.TypeEnum:
+			n, err := strconv.ParseInt(ex.Lexeme, 10, 64)
+			return bytecode.Value{Kind: bytecode.ValInt, I: n}, err == nil
+		case types.TypeChar:
+			if len(ex.Lexeme) == 1 {
+				return bytecode.Value{Kind: bytecode.ValChar, C: ex.Lexeme[0]}, true
+			}
+		case types.TypeString:
+			return bytecode.Value{Kind: bytecode.ValString, S: ex.Lexeme}, true
+		}
+
+	case *ast.IdentExpr:
+		if init, ok := c.consts[ex.Name]; ok && init != nil && c.isConst(ex.Name) {
+			return c.constValue(init)
+		}
+
+	case *ast.UnaryExpr:
+		v, ok := c.constValue(ex.Expr)
+		if !ok || v.Kind != bytecode.ValInt {
+			break
+		}
+		switch ex.Op {
+		case token.TokenMinus:
+			if v.I != math.MinInt64 {
+				return bytecode.Value{Kind: bytecode.ValInt, I: -v.I}, true
+			}
+		case token.TokenBitNot:
+			return bytecode.Value{Kind: bytecode.ValInt, I: ^v.I}, true
+		}
+
+	case *ast.CastExpr:
+		v, ok := c.constValue(ex.Expr)
+		if !ok {
+			break
+		}
+		to := bytecode.TypeInt
+		if ex.Type.Kind == types.TypeChar {
+			to = bytecode.TypeChar
+		} else if ex.Type.Kind != types.TypeInt {
+			break
+		}
+		if res, err := bytecode.Convert(v, to); err == nil {
+			return res, true
+		}
+
+	case *ast.BinaryExpr:
+		a, okA := c.constValue(ex.Left)
+		b, okB := c.constValue(ex.Right)
+		if !okA || !okB {
+			break
+		}
+		if ex.Op == token.TokenPlus {
+			if res, ok := bytecode.Concat(a, b); ok {
+				return res, true
+			}
+		}
+		op := opNames[ex.Op]
+		if a.Kind != bytecode.ValInt || b.Kind != bytecode.ValInt || bytecode.IntOverflows(op, a.I, b.I) {
+			break
+		}
+		if res, err := bytecode.IntOp(op, a.I, b.I); err == nil {
+			return bytecode.Value{Kind: bytecode.ValInt, I: res}, true
+		}
+	}
+	return bytecode.Value{}, false
+}
+
+// checkTarget возвращает тип левой части присваивания.
+func (c *Checker) checkTarget(target ast.Expr) types.Type {
+	if idx, ok := target.(*ast.IndexExpr); ok {
+		arr := c.checkExpression(idx.Array)
+		c.expectType(types.Type{Kind: types.TypeInt}, c.checkExpression(idx.Index), "index")
+		if arr.Kind == types.TypeString {
+			c.addError(typeMismatch, "strings are immutable, cannot assign to a character")
+			return types.Type{}
+		}
+		return c.elementType(arr)
+	}
+	return c.checkExpression(target)
+}
+
+func (c *Checker) checkExpression(expr ast.Expr) types.Type {
 	switch e := expr.(type) {
 	case *ast.IdentExpr:
-		if !c.isVarDeclared(e.Name) {
-			c.addError(undeclaredVariable,
-				fmt.Sprintf("variable '%s' is not declared", e.Name))
+		t, ok := c.lookupVar(e.Name)
+		if ok {
+			return t
 		}
+		// имя функции без вызова — значение функционального типа
+		if fn, ok := c.functions[e.Name]; ok {
+			if len(fn.TypeParams) > 0 {
+				c.addError(typeArgument,
+					fmt.Sprintf("generic function '%s' cannot be used as a value", e.Name))
+				return types.Type{}
+			}
+			return funcType(fn)
+		}
+		if _, _, ok := native.Lookup(e.Name); ok {
+			c.addError(typeMismatch,
+				fmt.Sprintf("built-in function '%s' cannot be used as a value", e.Name))
+			return types.Type{}
+		}
+		c.addError(undeclaredVariable,
+			fmt.Sprintf("variable '%s' is not declared", e.Name))
+		return t
+
+	case *ast.FuncLitExpr:
+		return c.checkFuncLit(e)
 
 	case *ast.CallExpr:
-		if ident, ok := e.Callee.(*ast.IdentExpr); ok {
-			_, inFuncs := c.functions[ident.Name]
-			_, inBuiltins := builtins[ident.Name]
+		args := make([]types.Type, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = c.checkExpression(arg)
+		}
 
-			if !inFuncs && !inBuiltins {
-				c.addError("UndeclaredFunction",
-					fmt.Sprintf("Function '%s' is not declared", ident.Name))
+		if fe, ok := e.Callee.(*ast.FieldExpr); ok {
+			obj := c.checkExpression(fe.Object)
+			if obj.Kind == types.TypeList || obj.Kind == types.TypeMap {
+				return c.checkMethod(e, fe, obj, args)
 			}
+			return c.checkIndirectCall(c.checkField(fe, obj), e.Args, args)
 		}
-		for _, arg := range e.Args {
-			c.checkExpression(arg)
+
+		// переменная функционального типа закрывает одноименную функцию
+		ident, ok := e.Callee.(*ast.IdentExpr)
+		if ok {
+			_, ok = c.lookupVar(ident.Name)
+			ok = !ok
+		}
+		if !ok {
+			return c.checkIndirectCall(c.checkExpression(e.Callee), e.Args, args)
 		}
+		if fn, ok := c.functions[ident.Name]; ok {
+			if len(fn.TypeParams) > 0 {
+				return c.checkGenericCall(fn, e.Args, args)
+			}
+			return c.checkCall(fn, e.Args, args)
+		}
+		if arity, ok := builtins[ident.Name]; ok {
+			if len(e.Args) != arity {
+				c.addError(builtinArgCount,
+					fmt.Sprintf("%s expects exactly %d argument(s), got %d", ident.Name, arity, len(e.Args)))
+				return types.Type{}
+			}
+			t := c.checkBuiltin(ident.Name, args)
+			if ident.Name == "print" || ident.Name == "str" {
+				e.Args[0] = c.enumName(e.Args[0], args[0])
+			}
+			return t
+		}
+		if _, f, ok := native.Lookup(ident.Name); ok {
+			fn := nativeDecl(f)
+			switch {
+			case f.Variadic != "":
+				return c.checkVariadicCall(fn, f.Variadic, e.Args, args)
+			case len(fn.TypeParams) > 0:
+				return c.checkGenericCall(fn, e.Args, args)
+			}
+			return c.checkCall(fn, e.Args, args)
+		}
+		c.addError("UndeclaredFunction",
+			fmt.Sprintf("Function '%s' is not declared", ident.Name))
+		return types.Type{}
 
 	case *ast.BinaryExpr:
-		c.checkExpression(e.Left)
-		c.checkExpression(e.Right)
+		l := c.checkExpression(e.Left)
+		r := c.checkExpression(e.Right)
+		if promotes(e.Op) {
+			t := arithmeticType(l, r)
+			e.Left = promote(e.Left, l, t)
+			e.Right = promote(e.Right, r, t)
+			l, r = arithmeticType(l, t), arithmeticType(r, t)
+		}
+		return c.checkBinary(e.Op, l, r)
+
+	case *ast.CastExpr:
+		from := c.checkExpression(e.Expr)
+		if from.Kind != types.TypeInvalid && !convertible(from, e.Type) {
+			c.addError(typeMismatch,
+				fmt.Sprintf("cannot convert %s to %s", from, e.Type))
+		}
+		return e.Type
 
 	case *ast.UnaryExpr:
-		c.checkExpression(e.Expr)
+		t := c.checkExpression(e.Expr)
+		switch {
+		case t.Kind == types.TypeInvalid:
+			if e.Op == token.TokenNot {
+				return types.Type{Kind: types.TypeBool}
+			}
+		case e.Op == token.TokenNot && t.Kind == types.TypeBool,
+			e.Op == token.TokenMinus && isNumeric(t),
+			e.Op == token.TokenBitNot && t.Kind == types.TypeInt:
+			return t
+		default:
+			c.addError(typeMismatch,
+				fmt.Sprintf("operator '%s' is not defined for %s", opNames[e.Op], t))
+		}
+		return types.Type{}
 
 	case *ast.IndexExpr:
-		c.checkExpression(e.Array)
-		c.checkExpression(e.Index)
+		arr := c.checkExpression(e.Array)
+		c.expectType(types.Type{Kind: types.TypeInt}, c.checkExpression(e.Index), "index")
+		return c.elementType(arr)
 
 	case *ast.NewArrayExpr:
-		c.checkExpression(e.Length)
+		for _, l := range e.Lengths {
+			c.expectType(types.Type{Kind: types.TypeInt}, c.checkExpression(l), "array length")
+		}
+		if !c.checkTypeKnown(e.ElementType) {
+			return types.Type{}
+		}
+		return e.ArrayType()
+
+	case *ast.NewStructExpr:
+		t := types.StructOf(e.Name)
+		if !c.checkTypeKnown(t) {
+			return types.Type{}
+		}
+		return t
+
+	case *ast.NewCollectionExpr:
+		if !c.checkTypeKnown(e.Type) {
+			return types.Type{}
+		}
+		return e.Type
+
+	case *ast.FieldExpr:
+		return c.checkField(e, c.checkExpression(e.Object))
+
+	case *ast.ArrayLiteralExpr:
+		// тип литерала — по первому известному элементу, расширенному до
+		// float, если среди элементов есть float; пустой литерал подходит
+		// любому массиву
+		var elem types.Type
+		elems := make([]types.Type, len(e.Elements))
+		for i, el := range e.Elements {
+			elems[i] = c.checkExpression(el)
+			if elem.Kind == types.TypeInvalid || elem.Kind == types.TypeNull || wider(elems[i], elem) {
+				elem = elems[i]
+			}
+		}
+		for i, el := range e.Elements {
+			t := widenLiteral(el, elems[i], elem)
+			c.expectAssignable(elem, t, "array literal element")
+			e.Elements[i] = promote(el, t, elem)
+		}
+		return types.ArrayOf(elem)
 
 	case *ast.LiteralExpr:
+		return e.Type
+	}
+	return types.Type{}
+}
+
+// checkField — поле структуры obj.
+func (c *Checker) checkField(e *ast.FieldExpr, obj types.Type) types.Type {
+	switch obj.Kind {
+	case types.TypeInvalid:
+		return types.Type{}
+	case types.TypeStruct:
+	default:
+		c.addError(typeMismatch,
+			fmt.Sprintf("cannot access field '%s' of non-struct type %s", e.Name, obj))
+		return types.Type{}
+	}
+	decl, ok := c.structs[obj.Name]
+	if !ok {
+		return types.Type{}
+	}
+	for i, f := range decl.Fields {
+		if f.Name == e.Name {
+			e.Index = i
+			return f.Type
+		}
+	}
+	c.addError(unknownField,
+		fmt.Sprintf("struct '%s' has no field '%s'", obj.Name, e.Name))
+	return types.Type{}
+}
+
+// checkMethod — вызов метода списка или словаря. xs.push(v) переписывается
+// в вызов встроенной функции $list.push(xs, v) и проверяется как он.
+func (c *Checker) checkMethod(e *ast.CallExpr, fe *ast.FieldExpr, obj types.Type, args []types.Type) types.Type {
+	kind := "list"
+	if obj.Kind == types.TypeMap {
+		kind = "map"
+	}
+	_, f, ok := native.Lookup("$" + kind + "." + fe.Name)
+	if !ok || len(f.Type.Params) == 0 {
+		c.addError(unknownField,
+			fmt.Sprintf("%s has no method '%s'", obj, fe.Name))
+		return types.Type{}
+	}
+	fn := nativeDecl(f)
+	fn.Name = kind + "." + fe.Name
+	if len(args) != len(fn.Params)-1 {
+		c.addError(argCount,
+			fmt.Sprintf("method '%s' expects %d argument(s), got %d", fn.Name, len(fn.Params)-1, len(args)))
+		return types.Type{}
+	}
+
+	e.Callee = &ast.IdentExpr{Name: f.Name}
+	e.Args = append([]ast.Expr{fe.Object}, e.Args...)
+	return c.checkGenericCall(fn, e.Args, append([]types.Type{obj}, args...))
+}
+
+// elementType — тип arr[i]: элемент массива или char для строки.
+func (c *Checker) elementType(arr types.Type) types.Type {
+	switch arr.Kind {
+	case types.TypeArray:
+		if arr.Elem != nil {
+			return *arr.Elem
+		}
+	case types.TypeString:
+		return types.Type{Kind: types.TypeChar}
+	case types.TypeInvalid:
+	default:
+		c.addError(typeMismatch,
+			fmt.Sprintf("cannot index value of type %s", arr))
+	}
+	return types.Type{}
+}
+
+// checkTypeKnown проверяет, что все структуры в типе объявлены.
+func (c *Checker) checkTypeKnown(t types.Type) bool {
+	switch t.Kind {
+	case types.TypeArray, types.TypeList:
+		if t.Elem != nil {
+			return c.checkTypeKnown(*t.Elem)
+		}
+	case types.TypeMap:
+		if !satisfies(*t.Key, "comparable") {
+			c.addError(typeMismatch,
+				fmt.Sprintf("invalid map key type %s: keys must be comparable", t.Key))
+			return false
+		}
+		return c.checkTypeKnown(*t.Elem)
+	case types.TypeStruct:
+		if _, ok := c.structs[t.Name]; !ok {
+			c.addError(unknownType,
+				fmt.Sprintf("type '%s' is not declared", t.Name))
+			return false
+		}
+	case types.TypeEnum:
+		if _, ok := c.enums[t.Name]; !ok {
+			c.addError(unknownType,
+				fmt.Sprintf("type '%s' is not declared", t.Name))
+			return false
+		}
+	case types.TypeFunction:
+		known := c.checkTypeKnown(*t.Return)
+		for _, p := range t.Params {
+			known = c.checkTypeKnown(p) && known
+		}
+		return known
+	}
+	return true
+}
+
+func (c *Checker) checkCall(fn *ast.FunctionDecl, argExprs []ast.Expr, args []types.Type) types.Type {
+	if len(args) != len(fn.Params) {
+		c.addError(argCount,
+			fmt.Sprintf("function '%s' expects %d argument(s), got %d", fn.Name, len(fn.Params), len(args)))
+		return fn.ReturnType
+	}
+	for i, p := range fn.Params {
+		args[i] = widenLiteral(argExprs[i], args[i], p.Type)
+		c.expectAssignable(p.Type, args[i],
+			fmt.Sprintf("argument '%s' of function '%s'", p.Name, fn.Name))
+		argExprs[i] = promote(argExprs[i], args[i], p.Type)
+	}
+	return fn.ReturnType
+}
+
+// nativeDecl — объявление встроенной функции: ее вызов проверяется
+// как вызов функции программы.
+func nativeDecl(f *native.Func) *ast.FunctionDecl {
+	fn := &ast.FunctionDecl{
+		Name:       f.Name,
+		TypeParams: f.TypeParams,
+		ReturnType: *f.Type.Return,
+	}
+	for i, t := range f.Type.Params {
+		fn.Params = append(fn.Params, ast.Param{Name: f.ParamNames[i], Type: t})
+	}
+	return fn
+}
+
+// checkVariadicCall: обязательные параметры проверяются как в обычном
+// вызове, остальные аргументы — каждый сам по себе, по ограничению.
+func (c *Checker) checkVariadicCall(fn *ast.FunctionDecl, constraint string, argExprs []ast.Expr, args []types.Type) types.Type {
+	n := len(fn.Params)
+	if len(args) < n {
+		c.addError(argCount,
+			fmt.Sprintf("function '%s' expects at least %d argument(s), got %d", fn.Name, n, len(args)))
+		return fn.ReturnType
+	}
+	c.checkCall(fn, argExprs[:n], args[:n])
+	for i, t := range args[n:] {
+		if !satisfies(t, constraint) {
+			c.addError(typeMismatch,
+				fmt.Sprintf("argument %d of function '%s': %s does not satisfy %s", n+i+1, fn.Name, t, constraint))
+		}
+		argExprs[n+i] = c.enumName(argExprs[n+i], t)
+	}
+	return fn.ReturnType
+}
+
+// checkGenericCall выводит аргументы типа из типов аргументов вызова,
+// проверяет ограничения и дальше проверяет вызов как обычный
+// с подставленными типами. Тело обобщенной функции проверено один раз.
+func (c *Checker) checkGenericCall(fn *ast.FunctionDecl, argExprs []ast.Expr, args []types.Type) types.Type {
+	if len(args) != len(fn.Params) {
+		c.addError(argCount,
+			fmt.Sprintf("function '%s' expects %d argument(s), got %d", fn.Name, len(fn.Params), len(args)))
+		return types.Type{}
+	}
+
+	bound := make(map[string]types.Type, len(fn.TypeParams))
+	for i, p := range fn.Params {
+		if err := unify(p.Type, args[i], bound); err != nil {
+			c.addError(typeArgument,
+				fmt.Sprintf("function '%s', argument '%s': %v", fn.Name, p.Name, err))
+			return types.Type{}
+		}
+	}
+	for _, tp := range fn.TypeParams {
+		t, ok := bound[tp.Name]
+		if !ok {
+			c.addError(typeArgument,
+				fmt.Sprintf("cannot infer type parameter '%s' of function '%s'", tp.Name, fn.Name))
+			return types.Type{}
+		}
+		if !satisfies(t, tp.Constraint) {
+			c.addError(typeArgument,
+				fmt.Sprintf("function '%s': %s does not satisfy %s (type parameter '%s')", fn.Name, t, tp.Constraint, tp.Name))
+			return types.Type{}
+		}
+	}
+
+	for i, p := range fn.Params {
+		want := substitute(p.Type, bound)
+		args[i] = widenLiteral(argExprs[i], args[i], want)
+		c.expectAssignable(want, args[i],
+			fmt.Sprintf("argument '%s' of function '%s'", p.Name, fn.Name))
+		argExprs[i] = promote(argExprs[i], args[i], want)
+	}
+	return substitute(fn.ReturnType, bound)
+}
+
+// unify сопоставляет тип параметра с типом аргумента и дописывает
+// в bound найденные параметры типа. Если T встречается и с int, и с float,
+// выводится float — int продвигается, как в обычном вызове.
+// Ошибка — T выведен из аргументов как два разных типа.
+func unify(param, arg types.Type, bound map[string]types.Type) error {
+	if arg.Kind == types.TypeInvalid {
+		// неизвестный тип аргумента: ошибка уже выдана, выводить нечего
+		if param.Kind == types.TypeParam {
+			if _, ok := bound[param.Name]; !ok {
+				bound[param.Name] = arg
+			}
+		}
+		return nil
+	}
+	switch param.Kind {
+	case types.TypeParam:
+		if arg.Kind == types.TypeNull {
+			return nil
+		}
+		prev, ok := bound[param.Name]
+		switch {
+		case !ok || prev.Kind == types.TypeInvalid:
+			bound[param.Name] = arg
+		case prev.Kind == types.TypeInt && arg.Kind == types.TypeFloat:
+			bound[param.Name] = arg
+		case prev.Kind == types.TypeFloat && arg.Kind == types.TypeInt:
+		case !prev.Equal(arg):
+			return fmt.Errorf("type parameter '%s' is both %s and %s", param.Name, prev, arg)
+		}
+	case types.TypeArray, types.TypeList:
+		if arg.Kind == param.Kind && param.Elem != nil && arg.Elem != nil {
+			return unify(*param.Elem, *arg.Elem, bound)
+		}
+	case types.TypeMap:
+		if arg.Kind != types.TypeMap {
+			return nil
+		}
+		if err := unify(*param.Key, *arg.Key, bound); err != nil {
+			return err
+		}
+		return unify(*param.Elem, *arg.Elem, bound)
+	case types.TypeFunction:
+		if arg.Kind != types.TypeFunction || len(arg.Params) != len(param.Params) {
+			return nil
+		}
+		for i := range param.Params {
+			if err := unify(param.Params[i], arg.Params[i], bound); err != nil {
+				return err
+			}
+		}
+		return unify(*param.Return, *arg.Return, bound)
+	}
+	return nil
+}
+
+// substitute заменяет в t параметры типа на выведенные типы.
+func substitute(t types.Type, bound map[string]types.Type) types.Type {
+	switch t.Kind {
+	case types.TypeParam:
+		if b, ok := bound[t.Name]; ok {
+			return b
+		}
+	case types.TypeArray:
+		if t.Elem != nil {
+			return types.ArrayOf(substitute(*t.Elem, bound))
+		}
+	case types.TypeList:
+		return types.ListOf(substitute(*t.Elem, bound))
+	case types.TypeMap:
+		return types.MapOf(substitute(*t.Key, bound), substitute(*t.Elem, bound))
+	case types.TypeFunction:
+		params := make([]types.Type, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = substitute(p, bound)
+		}
+		return types.FuncOf(params, substitute(*t.Return, bound))
+	}
+	return t
+}
+
+// satisfies: тип t подходит под ограничение. Параметр типа подходит,
+// если его собственное ограничение не слабее.
+func satisfies(t types.Type, constraint string) bool {
+	allowed, ok := constraints[constraint]
+	if !ok || t.Kind == types.TypeInvalid {
+		return true
+	}
+	if t.Kind == types.TypeParam {
+		rank, ok := constraintRank[t.Constraint]
+		return ok && rank <= constraintRank[constraint]
+	}
+	if allowed == nil {
+		return t.Kind != types.TypeVoid
+	}
+	for _, k := range allowed {
+		if t.Kind == k {
+			return true
+		}
 	}
+	return false
+}
+
+// checkIndirectCall — вызов значения функционального типа.
+func (c *Checker) checkIndirectCall(callee types.Type, argExprs []ast.Expr, args []types.Type) types.Type {
+	switch callee.Kind {
+	case types.TypeInvalid:
+		return types.Type{}
+	case types.TypeFunction:
+	default:
+		c.addError(typeMismatch,
+			fmt.Sprintf("cannot call value of type %s", callee))
+		return types.Type{}
+	}
+	if len(args) != len(callee.Params) {
+		c.addError(argCount,
+			fmt.Sprintf("%s expects %d argument(s), got %d", callee, len(callee.Params), len(args)))
+		return *callee.Return
+	}
+	for i, p := range callee.Params {
+		args[i] = widenLiteral(argExprs[i], args[i], p)
+		c.expectAssignable(p, args[i], fmt.Sprintf("argument %d of %s", i+1, callee))
+		argExprs[i] = promote(argExprs[i], args[i], p)
+	}
+	return *callee.Return
+}
+
+// enumName: значение перечисления печатается по имени константы —
+// e заменяется на ["Red", "Green", "Blue"][e]. Остальное не меняется.
+func (c *Checker) enumName(e ast.Expr, t types.Type) ast.Expr {
+	decl, ok := c.enums[t.Name]
+	if t.Kind != types.TypeEnum || !ok {
+		return e
+	}
+	names := &ast.ArrayLiteralExpr{}
+	for _, m := range decl.Members {
+		names.Elements = append(names.Elements, &ast.LiteralExpr{
+			Lexeme: m,
+			Token:  token.TokenText,
+			Type:   types.Type{Kind: types.TypeString},
+		})
+	}
+	return &ast.IndexExpr{Array: names, Index: e}
+}
+
+func funcType(fn *ast.FunctionDecl) types.Type {
+	params := make([]types.Type, len(fn.Params))
+	for i, p := range fn.Params {
+		params[i] = p.Type
+	}
+	return types.FuncOf(params, fn.ReturnType)
+}
+
+func (c *Checker) checkBuiltin(name string, args []types.Type) types.Type {
+	str := types.Type{Kind: types.TypeString}
+	integer := types.Type{Kind: types.TypeInt}
+
+	switch name {
+	case "print":
+		if args[0].Kind == types.TypeVoid {
+			c.addError(typeMismatch, "print: argument has no value")
+		}
+		return types.Type{Kind: types.TypeVoid}
+
+	case "len":
+		switch args[0].Kind {
+		case types.TypeInvalid, types.TypeArray, types.TypeString, types.TypeList, types.TypeMap:
+		default:
+			c.addError(typeMismatch,
+				fmt.Sprintf("len: expected array, string, list or map, got %s", args[0]))
+		}
+		return integer
+
+	case "str":
+		if !satisfies(args[0], "comparable") {
+			c.addError(typeMismatch,
+				fmt.Sprintf("str: cannot convert %s to string", args[0]))
+		}
+		return str
+
+	case "parseInt":
+		c.expectType(str, args[0], "parseInt argument")
+		return integer
+
+	case "parseFloat":
+		c.expectType(str, args[0], "parseFloat argument")
+		return types.Type{Kind: types.TypeFloat}
+	}
+	return types.Type{}
+}
+
+func (c *Checker) checkBinary(op token.TokenType, l, r types.Type) types.Type {
+	boolean := types.Type{Kind: types.TypeBool}
+	known := l.Kind != types.TypeInvalid && r.Kind != types.TypeInvalid
+
+	switch op {
+	case token.TokenAnd, token.TokenOr:
+		if known && (l.Kind != types.TypeBool || r.Kind != types.TypeBool) {
+			c.mismatch(op, l, r)
+		}
+		return boolean
+
+	case token.TokenEqual, token.TokenNotEqual:
+		if known && (l.Kind == types.TypeNull && !nullable(r) || r.Kind == types.TypeNull && !nullable(l)) {
+			t := l
+			if t.Kind == types.TypeNull {
+				t = r
+			}
+			c.addError(nullValue,
+				fmt.Sprintf("comparison with null: %s cannot be null", t))
+			return boolean
+		}
+		// значения параметра типа сравниваются, только если это разрешает ограничение
+		if known && (!assignable(l, r) && !assignable(r, l) ||
+			l.Kind == types.TypeParam && !satisfies(l, "comparable") ||
+			r.Kind == types.TypeParam && !satisfies(r, "comparable")) {
+			c.mismatch(op, l, r)
+		}
+		return boolean
+
+	case token.TokenLess, token.TokenLessEqual, token.TokenGreater, token.TokenGreaterEqual:
+		if known && (!l.Equal(r) || !isOrdered(l)) {
+			c.mismatch(op, l, r)
+		}
+		return boolean
+	}
+
+	if !known {
+		return types.Type{}
+	}
+
+	if bitwiseOps[op] {
+		if l.Kind != types.TypeInt || r.Kind != types.TypeInt {
+			c.mismatch(op, l, r)
+			return types.Type{}
+		}
+		return l
+	}
+
+	// конкатенация: string + string, string + char, char + string
+	if op == token.TokenPlus {
+		if l.Kind == types.TypeString && (r.Kind == types.TypeString || r.Kind == types.TypeChar) ||
+			l.Kind == types.TypeChar && r.Kind == types.TypeString {
+			return types.Type{Kind: types.TypeString}
+		}
+	}
+
+	if l.Equal(r) && isNumeric(l) {
+		return l
+	}
+	c.mismatch(op, l, r)
+	return types.Type{}
+}
+
+func (c *Checker) mismatch(op token.TokenType, l, r types.Type) {
+	c.addError(typeMismatch,
+		fmt.Sprintf("operator '%s' is not defined for %s and %s", opNames[op], l, r))
+}
+
+func (c *Checker) expectCondition(cond ast.Expr, stmt string) {
+	c.expectType(types.Type{Kind: types.TypeBool}, c.checkExpression(cond), stmt+" condition")
+}
+
+func (c *Checker) expectType(want, got types.Type, what string) {
+	if got.Kind == types.TypeInvalid || want.Equal(got) {
+		return
+	}
+	c.addError(typeMismatch,
+		fmt.Sprintf("%s: expected %s, got %s", what, want, got))
+}
+
+func (c *Checker) expectAssignable(dst, src types.Type, what string) {
+	if assignable(dst, src) {
+		return
+	}
+	if src.Kind == types.TypeNull {
+		c.addError(nullValue,
+			fmt.Sprintf("%s: %s cannot be null", what, dst))
+		return
+	}
+	c.addError(typeMismatch,
+		fmt.Sprintf("%s: cannot use %s as %s", what, src, dst))
+}
+
+// assignable: значение типа src можно записать в переменную типа dst.
+// null допустим для ссылочных типов, пустой литерал [] — для любого массива,
+// int неявно расширяется до float.
+func assignable(dst, src types.Type) bool {
+	if dst.Kind == types.TypeInvalid || src.Kind == types.TypeInvalid {
+		return true
+	}
+	if dst.Kind == types.TypeFloat && src.Kind == types.TypeInt {
+		return true
+	}
+	if src.Kind == types.TypeNull {
+		return nullable(dst)
+	}
+	if dst.Kind == types.TypeArray && src.Kind == types.TypeArray {
+		if dst.Elem == nil || src.Elem == nil {
+			return true
+		}
+		if src.Elem.Kind == types.TypeInvalid || dst.Elem.Kind == types.TypeInvalid {
+			return true
+		}
+		// [null, null] — массив любых ссылок
+		if src.Elem.Kind == types.TypeNull {
+			return nullable(*dst.Elem)
+		}
+		return dst.Elem.Equal(*src.Elem)
+	}
+	return dst.Equal(src)
+}
+
+// nullable: переменная типа t может хранить null. Это массивы, строки,
+// структуры, функции и коллекции; int, float, bool и char — нет.
+// Параметр типа тоже нет: вместо него может быть подставлен int.
+func nullable(t types.Type) bool {
+	switch t.Kind {
+	case types.TypeArray, types.TypeString, types.TypeStruct, types.TypeFunction,
+		types.TypeList, types.TypeMap, types.TypeNull:
+		return true
+	}
+	return false
+}
+
+// promotes: в арифметике и сравнениях int рядом с float продвигается до float.
+func promotes(op token.TokenType) bool {
+	return !bitwiseOps[op] && op != token.TokenAnd && op != token.TokenOr
+}
+
+// arithmeticType — float, если один из типов float, а другой int;
+// иначе l без изменений.
+func arithmeticType(l, r types.Type) types.Type {
+	if l.Kind == types.TypeInt && r.Kind == types.TypeFloat {
+		return r
+	}
+	return l
+}
+
+// promote оборачивает выражение типа from в преобразование к float,
+// если значение int записывается туда, где ждут float.
+func promote(e ast.Expr, from, to types.Type) ast.Expr {
+	if from.Kind == types.TypeInt && to.Kind == types.TypeFloat {
+		return &ast.CastExpr{Type: to, Expr: e}
+	}
+	return e
+}
+
+// wider: to — это from, где int (и int внутри массивов) заменен на float.
+func wider(to, from types.Type) bool {
+	if from.Kind == types.TypeInt && to.Kind == types.TypeFloat {
+		return true
+	}
+	if from.Kind == types.TypeArray && to.Kind == types.TypeArray && from.Elem != nil && to.Elem != nil {
+		return wider(*to.Elem, *from.Elem)
+	}
+	return false
+}
+
+// widenLiteral: литерал массива типа from, записываемый туда, где ждут
+// более широкий массив to (float[] вместо int[]), получает преобразования
+// у элементов и тип to. Массив-переменная не расширяется: в него можно
+// писать через другую ссылку.
+func widenLiteral(e ast.Expr, from, to types.Type) types.Type {
+	if !wider(to, from) || !literalWidens(e, from) {
+		return from
+	}
+	lit := e.(*ast.ArrayLiteralExpr)
+	for i, el := range lit.Elements {
+		widenLiteral(el, *from.Elem, *to.Elem)
+		lit.Elements[i] = promote(el, *from.Elem, *to.Elem)
+	}
+	return to
+}
+
+// literalWidens: e — литерал массива, и элементы-массивы тоже литералы.
+func literalWidens(e ast.Expr, t types.Type) bool {
+	lit, ok := e.(*ast.ArrayLiteralExpr)
+	if !ok {
+		return false
+	}
+	if t.Elem.Kind != types.TypeArray {
+		return true
+	}
+	for _, el := range lit.Elements {
+		if !literalWidens(el, *t.Elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// convertible: разрешенные явные преобразования между int, float и char.
+func convertible(from, to types.Type) bool {
+	if from.Equal(to) {
+		return isNumeric(from) || from.Kind == types.TypeChar
+	}
+	switch {
+	case isNumeric(from) && isNumeric(to):
+		return true
+	case from.Kind == types.TypeInt && to.Kind == types.TypeChar,
+		from.Kind == types.TypeChar && to.Kind == types.TypeInt,
+		from.Kind == types.TypeEnum && to.Kind == types.TypeInt:
+		return true
+	}
+	return false
+}
+
+func isNumeric(t types.Type) bool {
+	if t.Kind == types.TypeParam {
+		return satisfies(t, "number")
+	}
+	return t.Kind == types.TypeInt || t.Kind == types.TypeFloat
+}
+
+func isOrdered(t types.Type) bool {
+	if t.Kind == types.TypeParam {
+		return satisfies(t, "ordered")
+	}
+	return isNumeric(t) || t.Kind == types.TypeString || t.Kind == types.TypeChar
 }
 
 func (c *Checker) addError(errType, message string) {
@@ -163,28 +1353,56 @@ func (c *Checker) addError(errType, message string) {
 }
 
 func (c *Checker) pushScope() {
-	c.scopes = append(c.scopes, make(map[string]struct{}))
+	c.scopes = append(c.scopes, make(map[string]*variable))
 }
 
 func (c *Checker) popScope() {
 	c.scopes = c.scopes[:len(c.scopes)-1]
 }
 
-func (c *Checker) declareVar(name string) {
+func (c *Checker) declareVar(name string, typ types.Type, captured *bool) {
 	scope := c.scopes[len(c.scopes)-1]
 	if _, ok := scope[name]; ok {
 		c.addError(duplicateVar,
 			fmt.Sprintf("variable '%s already exists in this scope", name))
 		return
 	}
-	scope[name] = struct{}{}
+	// sort.x всегда означает функцию модуля sort
+	if _, ok := c.imports[name]; ok {
+		c.addError(importName,
+			fmt.Sprintf("variable '%s' conflicts with imported module '%s'", name, name))
+	}
+	// Color.Red всегда означает константу перечисления
+	if _, ok := c.enums[name]; ok {
+		c.addError(enumName,
+			fmt.Sprintf("variable '%s' conflicts with enum '%s'", name, name))
+	}
+	scope[name] = &variable{typ: typ, captured: captured}
 }
 
-func (c *Checker) isVarDeclared(name string) bool {
-	for _, scope := range c.scopes {
-		if _, ok := scope[name]; ok {
-			return true
+// lookupVar находит переменную. Локал объемлющей функции, найденный
+// изнутри анонимной, помечается захваченным и попадает в Captures каждой
+// анонимной функции между объявлением и местом использования.
+func (c *Checker) lookupVar(name string) (types.Type, bool) {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		v, ok := c.scopes[i][name]
+		if !ok {
+			continue
+		}
+		for j := len(c.lits) - 1; j >= 0 && c.lits[j].base > i && i > 0; j-- {
+			*v.captured = true
+			addCapture(c.lits[j].expr, name)
 		}
+		return v.typ, true
 	}
-	return false
+	return types.Type{}, false
+}
+
+func addCapture(lit *ast.FuncLitExpr, name string) {
+	for _, n := range lit.Captures {
+		if n == name {
+			return
+		}
+	}
+	lit.Captures = append(lit.Captures, name)
 }
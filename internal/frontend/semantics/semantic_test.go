@@ -0,0 +1,420 @@
+package semantics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+	"github.com/ChernykhITMO/compiler/internal/frontend/semantics"
+)
+
+// check возвращает ошибки проверки src; функция main добавляется сама.
+func check(t *testing.T, src string) []semantics.SemanticError {
+	t.Helper()
+	prog := parser.NewParser(lexer.NewLexer(src + "\nfunction main() void {\n}\n").Tokenize()).ParseProgram()
+	return semantics.NewChecker().Check(prog)
+}
+
+// expectError проверяет, что среди ошибок есть ошибка типа typ с текстом msg.
+func expectError(t *testing.T, src, typ, msg string) {
+	t.Helper()
+	errs := check(t, src)
+	for _, e := range errs {
+		if e.Type == typ && strings.Contains(e.Message, msg) {
+			return
+		}
+	}
+	t.Fatalf("errors %v, want [%s] %q", errs, typ, msg)
+}
+
+func TestBuiltinArgCount(t *testing.T) {
+	expectError(t, `
+function test() int {
+    return len([1, 2], 3)
+}
+`, "BuiltinArgCount", "len expects exactly 1 argument(s), got 2")
+}
+
+func TestStringTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		msg  string
+	}{
+		{"assign to character", `
+function test() void {
+    string s = "abc"
+    s[0] = 'x'
+}
+`, "strings are immutable"},
+		{"string minus int", `
+function test() string {
+    return "abc" - 1
+}
+`, "operator '-' is not defined for string and int"},
+		{"int plus string", `
+function test() string {
+    return 1 + "abc"
+}
+`, "operator '+' is not defined for int and string"},
+		{"str of array", `
+function test() string {
+    int[] a = [1]
+    return str(a)
+}
+`, "str: cannot convert int[] to string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, "TypeMismatch", tt.msg)
+		})
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		typ  string
+		msg  string
+	}{
+		{"duplicate struct", `
+struct P { int x }
+struct P { int y }
+`, "DuplicateStruct", "struct 'P' is already defined"},
+		{"duplicate field", `
+struct P { int x, int x }
+`, "DuplicateField", "field 'x' is already defined in struct 'P'"},
+		{"unknown field", `
+struct P { int x }
+
+function test() int {
+    P p = new P
+    return p.y
+}
+`, "UnknownField", "struct 'P' has no field 'y'"},
+		{"unknown type", `
+function test() void {
+    Q q = null
+}
+`, "UnknownType", "type 'Q' is not declared"},
+		{"field of int", `
+function test() int {
+    int a = 1
+    return a.x
+}
+`, "TypeMismatch", "cannot access field 'x' of non-struct type int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, tt.typ, tt.msg)
+		})
+	}
+}
+
+func TestConstErrors(t *testing.T) {
+	expectError(t, `
+const int N = 10
+
+function test() void {
+    N = 11
+}
+`, "ConstAssignment", "cannot assign to const 'N'")
+
+	expectError(t, `
+int x = 3
+const int N = x + 1
+`, "ConstInit", "const 'N' must be initialized with a constant expression")
+}
+
+func TestSwitchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		typ  string
+		msg  string
+	}{
+		{"duplicate case", `
+function test(int x) void {
+    switch (x) {
+    case 1, 2:
+        print(1)
+    case 2:
+        print(2)
+    }
+}
+`, "DuplicateCase", "duplicate case 2 in switch"},
+		{"variable case", `
+function test(int x, int y) void {
+    switch (x) {
+    case y:
+        print(1)
+    }
+}
+`, "CaseValue", "case value must be a constant expression"},
+		{"folded duplicate", `
+function test(int x) void {
+    switch (x) {
+    case 2:
+        print(1)
+    case 1 + 1:
+        print(2)
+    }
+}
+`, "DuplicateCase", "duplicate case 2 in switch"},
+		{"const expression duplicate", `
+const int A = 2
+
+function test(int x) void {
+    switch (x) {
+    case 4:
+        print(1)
+    case A * 2:
+        print(2)
+    }
+}
+`, "DuplicateCase", "duplicate case 4 in switch"},
+		{"string concat duplicate", `
+function test(string s) void {
+    switch (s) {
+    case "ab":
+        print(1)
+    case "a" + "b":
+        print(2)
+    }
+}
+`, "DuplicateCase", `duplicate case "ab" in switch`},
+		{"call case", `
+function one() int {
+    return 1
+}
+
+function test(int x) void {
+    switch (x) {
+    case one():
+        print(1)
+    }
+}
+`, "CaseValue", "case value must be a constant expression"},
+		{"division by zero case", `
+function test(int x) void {
+    switch (x) {
+    case 1 / 0:
+        print(1)
+    }
+}
+`, "CaseValue", "case value must be a constant expression"},
+		{"float subject", `
+function test(float x) void {
+    switch (x) {
+    case 1.0:
+        print(1)
+    }
+}
+`, "TypeMismatch", "switch: cannot switch on float"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, tt.typ, tt.msg)
+		})
+	}
+}
+
+func TestFunctionTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		typ  string
+		msg  string
+	}{
+		{"call int", `
+function test() int {
+    int x = 1
+    return x(2)
+}
+`, "TypeMismatch", "cannot call value of type int"},
+		{"indirect arg count", `
+function test() int {
+    function(int, int) int f = null
+    return f(1)
+}
+`, "ArgCount", "expects 2 argument(s), got 1"},
+		{"parameter type differs", `
+function neg(float x) float {
+    return -x
+}
+
+function test() void {
+    function(int) float f = neg
+}
+`, "TypeMismatch", "variable 'f': cannot use function(float) float as function(int) float"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, tt.typ, tt.msg)
+		})
+	}
+}
+
+func TestCatchClauseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		msg  string
+	}{
+		{"catch-all not last", `
+function test() void {
+    try {
+        throw 1
+    } catch {
+        print(0)
+    } catch (int n) {
+        print(n)
+    }
+}
+`, "catch without type must be the last catch clause"},
+		{"duplicate type", `
+function test() void {
+    try {
+        throw 1
+    } catch (int a) {
+        print(a)
+    } catch (int b) {
+        print(b)
+    }
+}
+`, "duplicate catch clause for int"},
+		{"array type", `
+function test() void {
+    try {
+        throw 1
+    } catch (int[] a) {
+        print(0)
+    }
+}
+`, "cannot catch by type int[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, "CatchClause", tt.msg)
+		})
+	}
+}
+
+func TestCollectionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		typ  string
+		msg  string
+	}{
+		{"array key", `
+function test() void {
+    map<int[], int> m = null
+}
+`, "TypeMismatch", "invalid map key type int[]: keys must be comparable"},
+		{"unknown method", `
+function test() void {
+    list<int> xs = new list<int>
+    xs.add(1)
+}
+`, "UnknownField", "list<int> has no method 'add'"},
+		{"method arg count", `
+function test() void {
+    map<string, int> m = new map<string, int>
+    m.set("a")
+}
+`, "ArgCount", "method 'map.set' expects 2 argument(s), got 1"},
+		{"element type", `
+function test() void {
+    list<int> xs = new list<int>
+    xs.push("a")
+}
+`, "TypeArgument", "function 'list.push', argument 'value': type parameter 'T' is both int and string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, tt.typ, tt.msg)
+		})
+	}
+}
+
+func TestNullValueErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		msg  string
+	}{
+		{"int variable", `
+function test() void {
+    int k = null
+}
+`, "variable 'k': int cannot be null"},
+		{"compare bool", `
+function test(bool b) bool {
+    return b == null
+}
+`, "comparison with null: bool cannot be null"},
+		{"type parameter", `
+function f<T>(T x) T {
+    return null
+}
+`, "T cannot be null"},
+		{"float argument", `
+function g(float x) float {
+    return x
+}
+
+function test() float {
+    return g(null)
+}
+`, "float cannot be null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, "NullValue", tt.msg)
+		})
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		typ  string
+		msg  string
+	}{
+		{"enum is not int", `
+enum Color { Red }
+
+function test() void {
+    int x = Color.Red
+}
+`, "TypeMismatch", "variable 'x': cannot use Color as int"},
+		{"different enums", `
+enum Color { Red }
+enum Size { Small }
+
+function test() bool {
+    return Color.Red == Size.Small
+}
+`, "TypeMismatch", "operator '==' is not defined for Color and Size"},
+		{"duplicate", `
+enum Color { Red }
+struct Color { int x }
+`, "DuplicateEnum", "type 'Color' is already defined"},
+		{"variable name", `
+enum Color { Red }
+
+function test() void {
+    int Color = 1
+}
+`, "EnumName", "variable 'Color' conflicts with enum 'Color'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectError(t, tt.src, tt.typ, tt.msg)
+		})
+	}
+}
@@ -44,7 +44,9 @@ func (v *ASTValidator) Validate(program *ast.Program) []ValidationError {
 	v.validateMainFunction(program)
 
 	for _, fn := range program.Functions {
-		v.validateBlock(fn.Body, fn.Name)
+		if fn.Extern == "" {
+			v.validateBlock(fn.Body, fn.Name)
+		}
 	}
 
 	return v.errors
@@ -67,6 +69,9 @@ func (v *ASTValidator) validateFunction(fun *ast.FunctionDecl) {
 		paramNames[p.Name] = struct{}{}
 	}
 
+	if fun.Extern != "" {
+		return
+	}
 	v.validateReturnStatements(fun.Body, fun.ReturnType, fun.Name)
 }
 
@@ -93,7 +98,7 @@ func (v *ASTValidator) validateMainFunction(program *ast.Program) {
 			}
 		}
 	}
-	if !hasMain {
+	if !hasMain && !program.Library {
 		v.addError(noMainFunction,
 			fmt.Sprintf("program must have 'main' function"))
 	}
@@ -134,9 +139,32 @@ func (v *ASTValidator) validateStatement(stmt ast.Stmt, context string) {
 		v.validateExpression(s.Condition, context)
 		v.validateBlock(s.Body, context)
 
+	case *ast.BlockStmt:
+		v.validateBlock(s, context)
+
+	case *ast.SwitchStmt:
+		v.validateExpression(s.Subject, context)
+		for _, c := range s.Cases {
+			for _, val := range c.Values {
+				v.validateExpression(val, context)
+			}
+		}
+		for _, b := range s.Blocks() {
+			v.validateBlock(b, context)
+		}
+
 	case *ast.ExprStmt:
 		v.validateExpression(s.Expr, context)
 
+	case *ast.TryStmt:
+		v.validateBlock(s.Body, context)
+		for _, c := range s.Catches {
+			v.validateBlock(c.Body, context)
+		}
+
+	case *ast.ThrowStmt:
+		v.validateExpression(s.Value, context)
+
 	case *ast.ForStmt:
 		if s.Init != nil {
 			v.validateStatement(s.Init, context)
@@ -173,9 +201,26 @@ func (v *ASTValidator) validateExpression(expr ast.Expr, context string) {
 		v.validateExpression(e.Index, context)
 
 	case *ast.NewArrayExpr:
-		v.validateExpression(e.Length, context)
+		for _, l := range e.Lengths {
+			v.validateExpression(l, context)
+		}
+
+	case *ast.ArrayLiteralExpr:
+		for _, el := range e.Elements {
+			v.validateExpression(el, context)
+		}
+
+	case *ast.FieldExpr:
+		v.validateExpression(e.Object, context)
 
-	case *ast.IdentExpr, *ast.LiteralExpr:
+	case *ast.CastExpr:
+		v.validateExpression(e.Expr, context)
+
+	case *ast.FuncLitExpr:
+		v.validateReturnStatements(e.Decl.Body, e.Decl.ReturnType, e.Decl.Name)
+		v.validateBlock(e.Decl.Body, e.Decl.Name)
+
+	case *ast.IdentExpr, *ast.LiteralExpr, *ast.NewStructExpr, *ast.NewCollectionExpr:
 		return
 	}
 }
@@ -207,10 +252,21 @@ func (v *ASTValidator) validateReturnStatements(block *ast.BlockStmt, expectedTy
 		case *ast.IfStmt:
 			v.validateReturnStatements(s.ThenBlock, expectedType, funcName)
 			v.validateReturnStatements(s.ElseBlock, expectedType, funcName)
+		case *ast.BlockStmt:
+			v.validateReturnStatements(s, expectedType, funcName)
 		case *ast.WhileStmt:
 			v.validateReturnStatements(s.Body, expectedType, funcName)
+		case *ast.SwitchStmt:
+			for _, b := range s.Blocks() {
+				v.validateReturnStatements(b, expectedType, funcName)
+			}
 		case *ast.ForStmt:
 			v.validateReturnStatements(s.Body, expectedType, funcName)
+		case *ast.TryStmt:
+			v.validateReturnStatements(s.Body, expectedType, funcName)
+			for _, c := range s.Catches {
+				v.validateReturnStatements(c.Body, expectedType, funcName)
+			}
 		}
 	}
 }
@@ -6,6 +6,7 @@ const (
 	TokenInvalid TokenType = iota
 	TokenNumber
 	TokenText
+	TokenCharText
 
 	TokenInt
 	TokenFloat
@@ -19,10 +20,21 @@ const (
 	TokenElse
 	TokenWhile
 	TokenFor
+	TokenSwitch
+	TokenCase
+	TokenDefault
 	TokenReturn
+	TokenTry
+	TokenCatch
+	TokenThrow
 	TokenBreak
 	TokenContinue
 	TokenNew
+	TokenStruct
+	TokenConst
+	TokenImport
+	TokenExport
+	TokenEnum
 	TokenNull
 	TokenTrue
 	TokenFalse
@@ -46,6 +58,25 @@ const (
 	TokenModulo
 	TokenPower
 
+	TokenBitAnd
+	TokenBitOr
+	TokenXor
+	TokenBitNot
+	TokenShiftLeft
+	TokenShiftRight
+
+	TokenPlusAssign
+	TokenMinusAssign
+	TokenMultiplyAssign
+	TokenDivideAssign
+	TokenModuloAssign
+	TokenBitAndAssign
+	TokenBitOrAssign
+	TokenShiftLeftAssign
+	TokenShiftRightAssign
+	TokenIncrement
+	TokenDecrement
+
 	TokenLeftParen
 	TokenRightParen
 	TokenLeftBrace
@@ -53,6 +84,8 @@ const (
 	TokenLeftBracket
 	TokenRightBracket
 	TokenComma
+	TokenDot
+	TokenColon
 	TokenNewline
 	TokenEnd
 	TokenSemicolon
@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ChernykhITMO/compiler/internal/frontend/token"
 )
@@ -18,11 +19,22 @@ const (
 	TypeVoid
 	TypeNull
 	TypeArray
+	TypeStruct
+	TypeFunction
+	TypeParam // параметр типа обобщенной функции
+	TypeList  // list<T>: растущий список
+	TypeMap   // map<K, V>: словарь
+	TypeEnum  // перечисление: значения — int, но тип отличен от int
 )
 
 type Type struct {
-	Kind BasicType
-	Elem *Type
+	Kind       BasicType
+	Elem       *Type  // элемент массива и списка, значение словаря
+	Key        *Type  // ключ для TypeMap
+	Name       string // имя для TypeStruct, TypeParam и TypeEnum
+	Params     []Type // для TypeFunction
+	Return     *Type  // для TypeFunction
+	Constraint string // ограничение TypeParam: any, comparable, ordered, number
 }
 
 func (t Type) String() string {
@@ -46,11 +58,92 @@ func (t Type) String() string {
 			return "[]"
 		}
 		return fmt.Sprintf("%s[]", t.Elem.String())
+	case TypeList:
+		return fmt.Sprintf("list<%s>", t.Elem)
+	case TypeMap:
+		return fmt.Sprintf("map<%s, %s>", t.Key, t.Elem)
+	case TypeStruct, TypeParam, TypeEnum:
+		return t.Name
+	case TypeFunction:
+		params := make([]string, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = p.String()
+		}
+		ret := "void"
+		if t.Return != nil {
+			ret = t.Return.String()
+		}
+		return fmt.Sprintf("function(%s) %s", strings.Join(params, ", "), ret)
 	default:
 		return "invalid"
 	}
 }
 
+func (t Type) Equal(o Type) bool {
+	if t.Kind != o.Kind {
+		return false
+	}
+	if t.Kind == TypeStruct || t.Kind == TypeParam || t.Kind == TypeEnum {
+		return t.Name == o.Name
+	}
+	if t.Kind == TypeFunction {
+		if len(t.Params) != len(o.Params) || (t.Return == nil) != (o.Return == nil) {
+			return false
+		}
+		for i := range t.Params {
+			if !t.Params[i].Equal(o.Params[i]) {
+				return false
+			}
+		}
+		return t.Return == nil || t.Return.Equal(*o.Return)
+	}
+	if t.Kind == TypeMap && !t.Key.Equal(*o.Key) {
+		return false
+	}
+	if t.Kind != TypeArray && t.Kind != TypeList && t.Kind != TypeMap {
+		return true
+	}
+	if t.Elem == nil || o.Elem == nil {
+		return t.Elem == o.Elem
+	}
+	return t.Elem.Equal(*o.Elem)
+}
+
+// StructOf — тип структуры с именем name.
+func StructOf(name string) Type {
+	return Type{Kind: TypeStruct, Name: name}
+}
+
+// EnumOf — тип перечисления с именем name.
+func EnumOf(name string) Type {
+	return Type{Kind: TypeEnum, Name: name}
+}
+
+// FuncOf — тип функции с параметрами params и результатом ret.
+func FuncOf(params []Type, ret Type) Type {
+	return Type{Kind: TypeFunction, Params: params, Return: &ret}
+}
+
+// ParamOf — параметр типа name с ограничением constraint.
+func ParamOf(name, constraint string) Type {
+	return Type{Kind: TypeParam, Name: name, Constraint: constraint}
+}
+
+// ArrayOf — тип массива с элементами elem.
+func ArrayOf(elem Type) Type {
+	return Type{Kind: TypeArray, Elem: &elem}
+}
+
+// ListOf — тип списка с элементами elem.
+func ListOf(elem Type) Type {
+	return Type{Kind: TypeList, Elem: &elem}
+}
+
+// MapOf — тип словаря с ключами key и значениями val.
+func MapOf(key, val Type) Type {
+	return Type{Kind: TypeMap, Key: &key, Elem: &val}
+}
+
 func TypeFromToken(tt token.TokenType) Type {
 	switch tt {
 	case token.TokenInt:
@@ -0,0 +1,431 @@
+package optimizer
+
+import (
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/token"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+// linear — значение вида base + off; base == "" означает константу off,
+// base из lenBase — длину массива len(name).
+type linear struct {
+	base string
+	off  int64
+}
+
+// upperBound — факт из условия цикла: v < bound (strict) или v <= bound.
+type upperBound struct {
+	name   string
+	bound  linear
+	strict bool
+}
+
+// boundsInfo — счетчики по всей функции, по именам. Имя считается
+// локальной переменной, только если local находит его в текущей области
+// видимости: иначе это глобальная переменная, которую может поменять вызов.
+type boundsInfo struct {
+	local    func(name string) bool
+	decls    map[string]int  // объявления, включая параметры
+	assigns  map[string]int  // присваивания, включая инициализатор объявления
+	params   map[string]bool // имя — параметр функции
+	inits    map[string]bool // объявление с инициализатором
+	captured map[string]bool // захвачена анонимной функцией
+	types    map[string]types.Type
+}
+
+func lenBase(name string) string {
+	return "len " + name
+}
+
+// boundsFunction помечает обращения arr[i + d] внутри циклов, для которых
+// доказано 0 <= i + d < len(arr). Длина известна, если arr ровно один раз
+// получает new T[E] до цикла, а E не меняется; диапазон i — из условия
+// цикла и того, что i только растет единственным обновлением в конце тела.
+func (o *Optimizer) boundsFunction(fn *ast.FunctionDecl) {
+	info := &boundsInfo{
+		local:    o.isLocal,
+		decls:    make(map[string]int),
+		assigns:  make(map[string]int),
+		params:   make(map[string]bool),
+		inits:    make(map[string]bool),
+		captured: make(map[string]bool),
+		types:    make(map[string]types.Type),
+	}
+	for _, p := range fn.Params {
+		info.decls[p.Name]++
+		info.params[p.Name] = true
+		info.types[p.Name] = p.Type
+		if p.Captured {
+			info.captured[p.Name] = true
+		}
+	}
+	walkBoundsCounts(fn.Body, info)
+
+	o.pushScope()
+	defer o.popScope()
+
+	o.declareParams(fn)
+	o.boundsBlock(fn.Body, info, map[string]linear{})
+}
+
+// isLocal: name — незахваченная локальная переменная или параметр.
+func (o *Optimizer) isLocal(name string) bool {
+	sym := o.resolve(name)
+	return sym != nil && !sym.captured
+}
+
+func walkBoundsCounts(block *ast.BlockStmt, info *boundsInfo) {
+	if block == nil {
+		return
+	}
+	for _, stmt := range block.Statements {
+		walkBoundsCountsStmt(stmt, info)
+	}
+}
+
+func walkBoundsCountsStmt(stmt ast.Stmt, info *boundsInfo) {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		info.decls[s.Name]++
+		info.types[s.Name] = s.Type
+		if s.Captured {
+			info.captured[s.Name] = true
+		}
+		if s.Init != nil {
+			info.assigns[s.Name]++
+			info.inits[s.Name] = true
+		}
+	case *ast.AssignStmt:
+		if id, ok := s.Target.(*ast.IdentExpr); ok {
+			info.assigns[id.Name]++
+		}
+	case *ast.IfStmt:
+		walkBoundsCounts(s.ThenBlock, info)
+		walkBoundsCounts(s.ElseBlock, info)
+	case *ast.WhileStmt:
+		walkBoundsCounts(s.Body, info)
+	case *ast.SwitchStmt:
+		for _, b := range s.Blocks() {
+			walkBoundsCounts(b, info)
+		}
+	case *ast.TryStmt:
+		walkBoundsCounts(s.Body, info)
+		for _, c := range s.Catches {
+			if c.Name != "" {
+				info.decls[c.Name]++
+				info.types[c.Name] = c.Type
+			}
+			walkBoundsCounts(c.Body, info)
+		}
+	case *ast.ForStmt:
+		if s.Init != nil {
+			walkBoundsCountsStmt(s.Init, info)
+		}
+		if s.Increment != nil {
+			walkBoundsCountsStmt(s.Increment, info)
+		}
+		walkBoundsCounts(s.Body, info)
+	case *ast.BlockStmt:
+		walkBoundsCounts(s, info)
+	}
+}
+
+// stable: переменная одна на всю функцию и не меняется после инициализации.
+func (info *boundsInfo) stable(name string) bool {
+	if info.decls[name] != 1 || info.captured[name] || !info.local(name) {
+		return false
+	}
+	if info.params[name] {
+		return info.assigns[name] == 0
+	}
+	return info.inits[name] && info.assigns[name] == 1
+}
+
+func (o *Optimizer) boundsBlock(block *ast.BlockStmt, info *boundsInfo, outer map[string]linear) {
+	if block == nil {
+		return
+	}
+	o.pushScope()
+	defer o.popScope()
+
+	lengths := make(map[string]linear, len(outer))
+	for name, l := range outer {
+		lengths[name] = l
+	}
+
+	for i, stmt := range block.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			o.declare(s)
+			if s.Init != nil {
+				info.recordLength(lengths, s.Name, s.Init)
+			}
+
+		case *ast.AssignStmt:
+			if id, ok := s.Target.(*ast.IdentExpr); ok {
+				info.recordLength(lengths, id.Name, s.Value)
+			}
+
+		case *ast.IfStmt:
+			o.boundsBlock(s.ThenBlock, info, lengths)
+			o.boundsBlock(s.ElseBlock, info, lengths)
+
+		case *ast.BlockStmt:
+			o.boundsBlock(s, info, lengths)
+
+		case *ast.SwitchStmt:
+			for _, b := range s.Blocks() {
+				o.boundsBlock(b, info, lengths)
+			}
+
+		case *ast.TryStmt:
+			o.boundsBlock(s.Body, info, lengths)
+			for _, c := range s.Catches {
+				o.pushScope()
+				o.declareCatch(c)
+				o.boundsBlock(c.Body, info, lengths)
+				o.popScope()
+			}
+
+		case *ast.WhileStmt:
+			info.boundsLoop(s.Condition, s.Body, nil, nil, block.Statements[:i], lengths)
+			o.boundsBlock(s.Body, info, lengths)
+
+		case *ast.ForStmt:
+			o.pushScope()
+			if decl, ok := s.Init.(*ast.VarDeclStmt); ok {
+				o.declare(decl)
+			}
+			info.boundsLoop(s.Condition, s.Body, s.Init, s.Increment, block.Statements[:i], lengths)
+			o.boundsBlock(s.Body, info, lengths)
+			o.popScope()
+		}
+	}
+}
+
+// recordLength запоминает len(name) = E для единственного присваивания
+// new T[E] или литерала массива.
+func (info *boundsInfo) recordLength(lengths map[string]linear, name string, value ast.Expr) {
+	if info.decls[name] != 1 || info.assigns[name] != 1 || info.captured[name] || !info.local(name) {
+		return
+	}
+	switch arr := value.(type) {
+	case *ast.ArrayLiteralExpr:
+		lengths[name] = linear{off: int64(len(arr.Elements))}
+	case *ast.NewArrayExpr:
+		l, ok := linearOf(arr.Lengths[0])
+		if !ok || (l.base != "" && !info.stable(l.base)) {
+			return
+		}
+		lengths[name] = l
+	}
+}
+
+// lengthOf — известная длина массива name внутри цикла. Граница len(name)
+// из условия годится, если name — массив и в цикле не переприсваивается:
+// если бы там был null, упал бы сам len в условии.
+func (info *boundsInfo) lengthOf(name string, bound linear, lengths map[string]linear, written map[string]int) (linear, bool) {
+	if written[name] != 0 || info.captured[name] || !info.local(name) {
+		return linear{}, false
+	}
+	if bound.base == lenBase(name) {
+		if info.decls[name] != 1 || info.types[name].Kind != types.TypeArray {
+			return linear{}, false
+		}
+		return linear{base: bound.base}, true
+	}
+	l, ok := lengths[name]
+	return l, ok
+}
+
+func (info *boundsInfo) boundsLoop(cond ast.Expr, body *ast.BlockStmt, init, incr ast.Stmt, before []ast.Stmt, lengths map[string]linear) {
+	if cond == nil || body == nil {
+		return
+	}
+
+	written := make(map[string]int)
+	countWrites(body, written)
+	if incr != nil {
+		countWritesStmt(incr, written)
+	}
+
+	for _, ub := range upperBounds(cond) {
+		// глобальную или захваченную переменную может поменять вызов внутри цикла
+		if written[ub.name] != 1 || !info.local(ub.name) || info.captured[ub.name] {
+			continue
+		}
+		if ub.bound.base != "" && !isLenBase(ub.bound.base) &&
+			(written[ub.bound.base] != 0 || !info.stable(ub.bound.base)) {
+			continue
+		}
+
+		// единственное обновление i — последний оператор тела или инкремент for,
+		// поэтому во всем теле i имеет значение, проверенное условием
+		update := incr
+		if update == nil && len(body.Statements) > 0 {
+			update = body.Statements[len(body.Statements)-1]
+		}
+		step, ok := updateStep(update, ub.name)
+		if !ok || step <= 0 {
+			continue
+		}
+		start, ok := entryValue(ub.name, init, before)
+		if !ok {
+			continue
+		}
+
+		// i + d <= max(i) + d
+		maxOff := ub.bound.off
+		if ub.strict {
+			maxOff--
+		}
+		markInBounds(body, func(idx *ast.IndexExpr) bool {
+			arr, ok := idx.Array.(*ast.IdentExpr)
+			if !ok {
+				return false
+			}
+			length, ok := info.lengthOf(arr.Name, ub.bound, lengths, written)
+			if !ok || length.base != ub.bound.base {
+				return false
+			}
+			at, ok := linearOf(idx.Index)
+			if !ok || at.base != ub.name {
+				return false
+			}
+			return start+at.off >= 0 && maxOff+at.off <= length.off-1
+		})
+	}
+}
+
+// upperBounds собирает факты i < B / i <= B из условия и его конъюнкций.
+func upperBounds(cond ast.Expr) []upperBound {
+	bin, ok := cond.(*ast.BinaryExpr)
+	if !ok {
+		return nil
+	}
+
+	if bin.Op == token.TokenAnd {
+		return append(upperBounds(bin.Left), upperBounds(bin.Right)...)
+	}
+
+	left, right, op := bin.Left, bin.Right, bin.Op
+	switch op {
+	case token.TokenGreater:
+		left, right, op = right, left, token.TokenLess
+	case token.TokenGreaterEqual:
+		left, right, op = right, left, token.TokenLessEqual
+	}
+	if op != token.TokenLess && op != token.TokenLessEqual {
+		return nil
+	}
+
+	id, ok := left.(*ast.IdentExpr)
+	if !ok {
+		return nil
+	}
+	bound, ok := linearOf(right)
+	if !ok {
+		return nil
+	}
+	return []upperBound{{name: id.Name, bound: bound, strict: op == token.TokenLess}}
+}
+
+func updateStep(stmt ast.Stmt, name string) (int64, bool) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || !isIdent(assign.Target, name) {
+		return 0, false
+	}
+	return stepOf(name, assign.Value)
+}
+
+// entryValue ищет значение переменной на входе в цикл: инициализатор for
+// или ближайшую предшествующую запись в том же блоке — она должна быть
+// присваиванием целого литерала.
+func entryValue(name string, init ast.Stmt, before []ast.Stmt) (int64, bool) {
+	if init != nil {
+		if v, ok := literalWrite(init, name); ok {
+			return v, true
+		}
+		written := make(map[string]int)
+		countWritesStmt(init, written)
+		if written[name] != 0 {
+			return 0, false
+		}
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		if v, ok := literalWrite(before[i], name); ok {
+			return v, true
+		}
+		written := make(map[string]int)
+		countWritesStmt(before[i], written)
+		if written[name] != 0 {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+func literalWrite(stmt ast.Stmt, name string) (int64, bool) {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		if s.Name == name && s.Init != nil {
+			return intValue(s.Init)
+		}
+	case *ast.AssignStmt:
+		if isIdent(s.Target, name) {
+			return intValue(s.Value)
+		}
+	}
+	return 0, false
+}
+
+func isLenBase(base string) bool {
+	return len(base) > 4 && base[:4] == "len "
+}
+
+func linearOf(e ast.Expr) (linear, bool) {
+	switch ex := e.(type) {
+	case *ast.CallExpr:
+		if isIdent(ex.Callee, "len") && len(ex.Args) == 1 {
+			if id, ok := ex.Args[0].(*ast.IdentExpr); ok {
+				return linear{base: lenBase(id.Name)}, true
+			}
+		}
+	case *ast.LiteralExpr:
+		v, ok := intValue(ex)
+		return linear{off: v}, ok
+	case *ast.IdentExpr:
+		return linear{base: ex.Name}, true
+	case *ast.BinaryExpr:
+		switch ex.Op {
+		case token.TokenPlus:
+			if l, ok := linearOf(ex.Left); ok && l.base != "" {
+				if c, ok := intValue(ex.Right); ok {
+					return linear{base: l.base, off: l.off + c}, true
+				}
+			}
+			if l, ok := linearOf(ex.Right); ok && l.base != "" {
+				if c, ok := intValue(ex.Left); ok {
+					return linear{base: l.base, off: l.off + c}, true
+				}
+			}
+		case token.TokenMinus:
+			if l, ok := linearOf(ex.Left); ok && l.base != "" {
+				if c, ok := intValue(ex.Right); ok {
+					return linear{base: l.base, off: l.off - c}, true
+				}
+			}
+		}
+	}
+	return linear{}, false
+}
+
+func markInBounds(body *ast.BlockStmt, proven func(*ast.IndexExpr) bool) {
+	mapBlock(body, func(e ast.Expr) ast.Expr {
+		if idx, ok := e.(*ast.IndexExpr); ok && proven(idx) {
+			idx.InBounds = true
+		}
+		return nil
+	})
+}
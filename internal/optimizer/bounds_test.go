@@ -0,0 +1,179 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+)
+
+// indexes собирает обращения к массивам в теле: "a[i + 1]" -> InBounds.
+func indexes(block *ast.BlockStmt) map[string]bool {
+	found := make(map[string]bool)
+	var expr func(e ast.Expr)
+	expr = func(e ast.Expr) {
+		switch ex := e.(type) {
+		case *ast.IndexExpr:
+			found[render(ex.Array)+"["+render(ex.Index)+"]"] = ex.InBounds
+			expr(ex.Index)
+		case *ast.BinaryExpr:
+			expr(ex.Left)
+			expr(ex.Right)
+		}
+	}
+	var stmt func(s ast.Stmt)
+	stmt = func(s ast.Stmt) {
+		switch st := s.(type) {
+		case *ast.VarDeclStmt:
+			expr(st.Init)
+		case *ast.AssignStmt:
+			expr(st.Target)
+			expr(st.Value)
+		case *ast.ReturnStmt:
+			expr(st.Value)
+		case *ast.BlockStmt:
+			for _, inner := range st.Statements {
+				stmt(inner)
+			}
+		case *ast.IfStmt:
+			stmt(st.ThenBlock)
+			if st.ElseBlock != nil {
+				stmt(st.ElseBlock)
+			}
+		case *ast.WhileStmt:
+			stmt(st.Body)
+		case *ast.ForStmt:
+			stmt(st.Body)
+		}
+	}
+	stmt(block)
+	return found
+}
+
+func TestBoundsProven(t *testing.T) {
+	prog, _ := optimize(t, `
+function f(int n) int {
+    int[] a = new int[n]
+    int s = 0
+    int i = 0
+    while (i < n - 1) {
+        s = s + a[i] + a[i + 1] + a[i + 2] + a[i - 1]
+        i = i + 1
+    }
+    return s
+}
+`)
+	want := map[string]bool{
+		"a[i]":       true,
+		"a[(i + 1)]": true,
+		"a[(i + 2)]": false, // i + 2 может равняться n
+		"a[(i - 1)]": false, // при i == 0 индекс отрицательный
+	}
+	got := indexes(function(t, prog, "f").Body)
+	for idx, in := range want {
+		if got[idx] != in {
+			t.Errorf("%s: InBounds = %v, want %v", idx, got[idx], in)
+		}
+	}
+}
+
+func TestBoundsNotProven(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"length changes", `
+function f(int n) int {
+    int[] a = new int[n]
+    int s = 0
+    for (int i = 0; i < n; i = i + 1) {
+        s = s + a[i]
+        n = n - 1
+    }
+    return s
+}
+`},
+		{"array reassigned", `
+function f(int n) int {
+    int[] a = new int[n]
+    a = new int[1]
+    int s = 0
+    for (int i = 0; i < n; i = i + 1) {
+        s = s + a[i]
+    }
+    return s
+}
+`},
+		{"index updated twice", `
+function f(int n) int {
+    int[] a = new int[n]
+    int s = 0
+    for (int i = 0; i < n; i = i + 1) {
+        i = i + 1
+        s = s + a[i]
+    }
+    return s
+}
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prog, _ := optimize(t, tt.src)
+			if got := indexes(function(t, prog, "f").Body); got["a[i]"] {
+				t.Fatalf("a[i] marked in bounds")
+			}
+		})
+	}
+}
+
+func TestBoundsFromLen(t *testing.T) {
+	prog, _ := optimize(t, `
+function f(int[] a) int {
+    int[] b = [1, 2, 3]
+    int s = 0
+    for (int i = 0; i < len(a); i = i + 1) {
+        s = s + a[i]
+    }
+    for (int j = 0; j < 3; j = j + 1) {
+        s = s + b[j]
+    }
+    return s
+}
+`)
+	got := indexes(function(t, prog, "f").Body)
+	if !got["a[i]"] || !got["b[j]"] {
+		t.Fatalf("InBounds = %v, want a[i] and b[j] proven", got)
+	}
+}
+
+func TestBoundsGlobalShadowedLater(t *testing.T) {
+	// глобальный a меняет вызов внутри цикла, а локальный a объявлен
+	// позже — по имени они совпадают, но доказывать по нему нельзя
+	prog, _ := optimize(t, `
+int[] a = new int[10]
+bool big = true
+
+function toggle() void {
+    if (big) {
+        a = new int[1]
+    } else {
+        a = new int[10]
+    }
+    big = !big
+}
+
+function f() int {
+    int i = 0
+    while (i < len(a)) {
+        toggle()
+        a[i] = 1
+        toggle()
+        i = i + 1
+    }
+    int[] a = new int[3]
+    return len(a)
+}
+`)
+	if got := indexes(function(t, prog, "f").Body); got["a[i]"] {
+		t.Fatalf("global a[i] marked in bounds")
+	}
+}
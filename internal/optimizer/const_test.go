@@ -0,0 +1,18 @@
+package optimizer_test
+
+import "testing"
+
+func TestGlobalConstSubstituted(t *testing.T) {
+	prog, _ := optimize(t, `
+const int N = 1000
+const int M = N * 2
+int counter = 5
+
+function f() int {
+    return M + 1 + counter
+}
+`)
+	if got := render(returned(t, function(t, prog, "f"))); got != "(2001 + counter)" {
+		t.Fatalf("return %s, want (2001 + counter)", got)
+	}
+}
@@ -0,0 +1,594 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/token"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+var arithOps = map[token.TokenType]string{
+	token.TokenPlus:     "+",
+	token.TokenMinus:    "-",
+	token.TokenMultiply: "*",
+	token.TokenDivide:   "/",
+	token.TokenModulo:   "%",
+	token.TokenPower:    "^",
+
+	token.TokenBitAnd:     "&",
+	token.TokenBitOr:      "|",
+	token.TokenXor:        "xor",
+	token.TokenShiftLeft:  "<<",
+	token.TokenShiftRight: ">>",
+}
+
+var castKinds = map[types.BasicType]bytecode.TypeKind{
+	types.TypeInt:   bytecode.TypeInt,
+	types.TypeFloat: bytecode.TypeFloat,
+	types.TypeChar:  bytecode.TypeChar,
+}
+
+func (o *Optimizer) foldFunction(fn *ast.FunctionDecl) {
+	o.fn = fn
+	o.assigned = make(map[string]struct{})
+	collectAssigned(fn.Body, o.assigned)
+
+	o.pushScope()
+	defer o.popScope()
+
+	o.declareParams(fn)
+
+	o.foldBlock(fn.Body)
+}
+
+// foldGlobals сворачивает инициализаторы глобальных переменных и запоминает
+// значения const, чтобы подставлять их в функции.
+func (o *Optimizer) foldGlobals(globals []*ast.VarDeclStmt) {
+	o.fn = nil
+	o.assigned = make(map[string]struct{})
+	o.consts = make(map[string]*ast.LiteralExpr)
+
+	for _, g := range globals {
+		if g.Init == nil {
+			continue
+		}
+		g.Init = o.foldExpr(g.Init)
+		if lit, ok := g.Init.(*ast.LiteralExpr); ok && g.Const {
+			o.consts[g.Name] = lit
+		}
+	}
+}
+
+// foldBlock сворачивает операторы блока и отрезает все, что идет после
+// return/break/continue/throw.
+func (o *Optimizer) foldBlock(block *ast.BlockStmt) {
+	if block == nil {
+		return
+	}
+	o.pushScope()
+	defer o.popScope()
+
+	out := block.Statements[:0]
+	for _, stmt := range block.Statements {
+		stmt = o.foldStmt(stmt)
+		if stmt == nil {
+			continue
+		}
+		out = append(out, stmt)
+		if terminates(stmt) {
+			break
+		}
+	}
+	block.Statements = out
+}
+
+// foldStmt возвращает nil, если оператор можно выбросить целиком.
+func (o *Optimizer) foldStmt(stmt ast.Stmt) ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		if s.Init != nil {
+			s.Init = o.foldExpr(s.Init)
+		}
+		o.declare(s)
+
+	case *ast.AssignStmt:
+		switch t := s.Target.(type) {
+		case *ast.IndexExpr:
+			t.Array = o.foldExpr(t.Array)
+			t.Index = o.foldExpr(t.Index)
+		case *ast.FieldExpr:
+			t.Object = o.foldExpr(t.Object)
+		}
+		s.Value = o.foldExpr(s.Value)
+
+	case *ast.ExprStmt:
+		s.Expr = o.foldExpr(s.Expr)
+
+	case *ast.ReturnStmt:
+		if s.Value != nil {
+			s.Value = o.foldExpr(s.Value)
+		}
+
+	case *ast.IfStmt:
+		s.Condition = o.foldExpr(s.Condition)
+		if cond, ok := boolLiteral(s.Condition); ok {
+			if cond {
+				o.foldBlock(s.ThenBlock)
+				return s.ThenBlock
+			}
+			if s.ElseBlock == nil {
+				return nil
+			}
+			o.foldBlock(s.ElseBlock)
+			return s.ElseBlock
+		}
+		o.foldBlock(s.ThenBlock)
+		o.foldBlock(s.ElseBlock)
+
+	case *ast.WhileStmt:
+		s.Condition = o.foldExpr(s.Condition)
+		if cond, ok := boolLiteral(s.Condition); ok && !cond {
+			return nil
+		}
+		o.foldBlock(s.Body)
+
+	case *ast.SwitchStmt:
+		// ветку не выбираем даже при константном subject: break в ее теле
+		// относится к switch, а не к внешнему циклу
+		s.Subject = o.foldExpr(s.Subject)
+		for _, c := range s.Cases {
+			for i, v := range c.Values {
+				c.Values[i] = o.foldExpr(v)
+			}
+		}
+		for _, b := range s.Blocks() {
+			o.foldBlock(b)
+		}
+
+	case *ast.ForStmt:
+		o.pushScope()
+		defer o.popScope()
+
+		if s.Init != nil {
+			s.Init = o.foldStmt(s.Init)
+		}
+		if s.Condition != nil {
+			s.Condition = o.foldExpr(s.Condition)
+			if cond, ok := boolLiteral(s.Condition); ok && !cond {
+				if s.Init == nil {
+					return nil
+				}
+				return &ast.BlockStmt{Statements: []ast.Stmt{s.Init}}
+			}
+		}
+		if s.Increment != nil {
+			s.Increment = o.foldStmt(s.Increment)
+		}
+		o.foldBlock(s.Body)
+
+	case *ast.TryStmt:
+		o.foldBlock(s.Body)
+		for _, c := range s.Catches {
+			o.pushScope()
+			o.declareCatch(c)
+			o.foldBlock(c.Body)
+			o.popScope()
+		}
+
+	case *ast.ThrowStmt:
+		s.Value = o.foldExpr(s.Value)
+
+	case *ast.BlockStmt:
+		o.foldBlock(s)
+	}
+
+	return stmt
+}
+
+func terminates(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt, *ast.BreakStmt, *ast.ContinueStmt, *ast.ThrowStmt:
+		return true
+	case *ast.IfStmt:
+		return blockTerminates(s.ThenBlock) && blockTerminates(s.ElseBlock)
+	case *ast.BlockStmt:
+		return blockTerminates(s)
+	case *ast.TryStmt:
+		for _, c := range s.Catches {
+			if !blockTerminates(c.Body) {
+				return false
+			}
+		}
+		return blockTerminates(s.Body)
+	default:
+		return false
+	}
+}
+
+func blockTerminates(block *ast.BlockStmt) bool {
+	if block == nil || len(block.Statements) == 0 {
+		return false
+	}
+	return terminates(block.Statements[len(block.Statements)-1])
+}
+
+func (o *Optimizer) foldExpr(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.IdentExpr:
+		if lit := o.constantOf(e.Name); lit != nil {
+			return lit
+		}
+
+	case *ast.UnaryExpr:
+		e.Expr = o.foldExpr(e.Expr)
+		if v, ok := literalValue(e.Expr); ok {
+			if res, ok := o.foldUnary(e.Op, v); ok {
+				return valueLiteral(res)
+			}
+		}
+
+	case *ast.BinaryExpr:
+		e.Left = o.foldExpr(e.Left)
+		e.Right = o.foldExpr(e.Right)
+
+		if e.Op == token.TokenAnd || e.Op == token.TokenOr {
+			return foldLogical(e)
+		}
+
+		a, okA := literalValue(e.Left)
+		b, okB := literalValue(e.Right)
+		if okA && okB {
+			if res, ok := o.foldBinary(e.Op, a, b); ok {
+				return valueLiteral(res)
+			}
+		}
+
+	case *ast.CallExpr:
+		for i, arg := range e.Args {
+			e.Args[i] = o.foldExpr(arg)
+		}
+		if isIdent(e.Callee, "len") && len(e.Args) == 1 {
+			if v, ok := literalValue(e.Args[0]); ok && v.Kind == bytecode.ValString {
+				return intLiteral(int64(len(v.S)))
+			}
+		}
+
+	case *ast.IndexExpr:
+		e.Array = o.foldExpr(e.Array)
+		e.Index = o.foldExpr(e.Index)
+
+	case *ast.NewArrayExpr:
+		for i, l := range e.Lengths {
+			e.Lengths[i] = o.foldExpr(l)
+		}
+
+	case *ast.ArrayLiteralExpr:
+		for i, el := range e.Elements {
+			e.Elements[i] = o.foldExpr(el)
+		}
+
+	case *ast.FieldExpr:
+		e.Object = o.foldExpr(e.Object)
+
+	case *ast.CastExpr:
+		e.Expr = o.foldExpr(e.Expr)
+		if v, ok := literalValue(e.Expr); ok {
+			if res, err := bytecode.Convert(v, castKinds[e.Type.Kind]); err == nil {
+				return valueLiteral(res)
+			}
+		}
+
+	case *ast.FuncLitExpr:
+		// тело видит локалы объемлющей функции, поэтому сворачивается
+		// в ее областях видимости
+		collectAssigned(e.Decl.Body, o.assigned)
+		o.pushScope()
+		o.declareParams(e.Decl)
+		o.foldBlock(e.Decl.Body)
+		o.popScope()
+	}
+
+	return expr
+}
+
+// constantOf возвращает копию литерала, если переменная объявлена с
+// константным инициализатором и больше нигде не присваивается,
+// или если это глобальная const.
+func (o *Optimizer) constantOf(name string) ast.Expr {
+	sym := o.resolve(name)
+	var lit *ast.LiteralExpr
+	switch {
+	case sym == nil:
+		// не локал — возможно, глобальная константа
+		c, ok := o.consts[name]
+		if !ok {
+			return nil
+		}
+		lit = c
+	default:
+		if _, ok := o.assigned[name]; ok {
+			return nil
+		}
+		if sym.decl == nil || sym.decl.Init == nil {
+			return nil
+		}
+		l, ok := sym.decl.Init.(*ast.LiteralExpr)
+		if !ok {
+			return nil
+		}
+		lit = l
+	}
+	cp := *lit
+	return &cp
+}
+
+// foldLogical повторяет семантику && и || в VM: левый операнд всегда
+// вычисляется, правый — только если нужен.
+func foldLogical(e *ast.BinaryExpr) ast.Expr {
+	left, ok := boolLiteral(e.Left)
+	if !ok {
+		return e
+	}
+	if e.Op == token.TokenAnd {
+		if !left {
+			return e.Left
+		}
+		return e.Right
+	}
+	if left {
+		return e.Left
+	}
+	return e.Right
+}
+
+func (o *Optimizer) foldUnary(op token.TokenType, v bytecode.Value) (bytecode.Value, bool) {
+	switch op {
+	case token.TokenMinus:
+		switch v.Kind {
+		case bytecode.ValInt:
+			if v.I == math.MinInt64 {
+				o.overflow("-(%d)", v.I)
+				return bytecode.Value{}, false
+			}
+			return bytecode.Value{Kind: bytecode.ValInt, I: -v.I}, true
+		case bytecode.ValFloat:
+			return bytecode.Value{Kind: bytecode.ValFloat, F: -v.F}, true
+		}
+	case token.TokenNot:
+		if v.Kind == bytecode.ValBool {
+			return bytecode.Value{Kind: bytecode.ValBool, B: !v.B}, true
+		}
+	case token.TokenBitNot:
+		if v.Kind == bytecode.ValInt {
+			return bytecode.Value{Kind: bytecode.ValInt, I: ^v.I}, true
+		}
+	}
+	return bytecode.Value{}, false
+}
+
+// foldBinary возвращает false, если выражение должно остаться до рантайма:
+// смешанные типы, деление на ноль и т.п. — ошибку выдаст VM.
+func (o *Optimizer) foldBinary(op token.TokenType, a, b bytecode.Value) (bytecode.Value, bool) {
+	if op == token.TokenPlus {
+		if s, ok := bytecode.Concat(a, b); ok {
+			return s, true
+		}
+	}
+
+	if a.Kind != b.Kind {
+		switch op {
+		case token.TokenEqual:
+			return boolValue(false), true
+		case token.TokenNotEqual:
+			return boolValue(true), true
+		}
+		return bytecode.Value{}, false
+	}
+
+	if sym, ok := arithOps[op]; ok {
+		switch a.Kind {
+		case bytecode.ValInt:
+			// переполнение остается до рантайма: VM либо обернет результат,
+			// либо, в режиме проверки, выдаст ошибку
+			if bytecode.IntOverflows(sym, a.I, b.I) {
+				o.overflow("%d %s %d", a.I, sym, b.I)
+				return bytecode.Value{}, false
+			}
+			res, err := bytecode.IntOp(sym, a.I, b.I)
+			if err != nil {
+				return bytecode.Value{}, false
+			}
+			return bytecode.Value{Kind: bytecode.ValInt, I: res}, true
+		case bytecode.ValFloat:
+			res, err := bytecode.FloatOp(sym, a.F, b.F)
+			if err != nil {
+				return bytecode.Value{}, false
+			}
+			return bytecode.Value{Kind: bytecode.ValFloat, F: res}, true
+		}
+		return bytecode.Value{}, false
+	}
+
+	switch op {
+	case token.TokenEqual, token.TokenNotEqual:
+		eq, ok := equalValues(a, b)
+		if !ok {
+			return bytecode.Value{}, false
+		}
+		if op == token.TokenNotEqual {
+			eq = !eq
+		}
+		return boolValue(eq), true
+
+	case token.TokenLess, token.TokenLessEqual, token.TokenGreater, token.TokenGreaterEqual:
+		var cmp int
+		switch a.Kind {
+		case bytecode.ValInt:
+			cmp = compare(a.I, b.I)
+		case bytecode.ValFloat:
+			if math.IsNaN(a.F) || math.IsNaN(b.F) {
+				return boolValue(false), true
+			}
+			cmp = compare(a.F, b.F)
+		case bytecode.ValChar:
+			cmp = compare(a.C, b.C)
+		case bytecode.ValString:
+			cmp = compare(a.S, b.S)
+		default:
+			return bytecode.Value{}, false
+		}
+		switch op {
+		case token.TokenLess:
+			return boolValue(cmp < 0), true
+		case token.TokenLessEqual:
+			return boolValue(cmp <= 0), true
+		case token.TokenGreater:
+			return boolValue(cmp > 0), true
+		default:
+			return boolValue(cmp >= 0), true
+		}
+	}
+
+	return bytecode.Value{}, false
+}
+
+func (o *Optimizer) overflow(format string, args ...any) {
+	where := "global initializer"
+	if o.fn != nil {
+		where = fmt.Sprintf("function '%s'", o.fn.Name)
+	}
+	o.addWarning(foldedOverflow,
+		fmt.Sprintf("integer overflow in constant expression %s in %s",
+			fmt.Sprintf(format, args...), where))
+}
+
+func compare[T int64 | float64 | byte | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func equalValues(a, b bytecode.Value) (bool, bool) {
+	switch a.Kind {
+	case bytecode.ValNull:
+		return true, true
+	case bytecode.ValBool:
+		return a.B == b.B, true
+	case bytecode.ValInt:
+		return a.I == b.I, true
+	case bytecode.ValFloat:
+		return a.F == b.F, true
+	case bytecode.ValString:
+		return a.S == b.S, true
+	case bytecode.ValChar:
+		return a.C == b.C, true
+	default:
+		return false, false
+	}
+}
+
+func boolValue(b bool) bytecode.Value {
+	return bytecode.Value{Kind: bytecode.ValBool, B: b}
+}
+
+func boolLiteral(e ast.Expr) (bool, bool) {
+	v, ok := literalValue(e)
+	if !ok || v.Kind != bytecode.ValBool {
+		return false, false
+	}
+	return v.B, true
+}
+
+// literalValue разбирает литерал так же, как это делает backend.Compiler.
+func literalValue(e ast.Expr) (bytecode.Value, bool) {
+	lit, ok := e.(*ast.LiteralExpr)
+	if !ok {
+		return bytecode.Value{}, false
+	}
+
+	switch lit.Type.Kind {
+	case types.TypeInt, types.TypeEnum:
+		i, err := strconv.ParseInt(lit.Lexeme, 10, 64)
+		if err != nil {
+			return bytecode.Value{}, false
+		}
+		return bytecode.Value{Kind: bytecode.ValInt, I: i}, true
+	case types.TypeFloat:
+		f, err := strconv.ParseFloat(lit.Lexeme, 64)
+		if err != nil {
+			return bytecode.Value{}, false
+		}
+		return bytecode.Value{Kind: bytecode.ValFloat, F: f}, true
+	case types.TypeBool:
+		b, err := strconv.ParseBool(lit.Lexeme)
+		if err != nil {
+			return bytecode.Value{}, false
+		}
+		return boolValue(b), true
+	case types.TypeString:
+		return bytecode.Value{Kind: bytecode.ValString, S: lit.Lexeme}, true
+	case types.TypeChar:
+		if len(lit.Lexeme) != 1 {
+			return bytecode.Value{}, false
+		}
+		return bytecode.Value{Kind: bytecode.ValChar, C: lit.Lexeme[0]}, true
+	case types.TypeNull:
+		return bytecode.Value{Kind: bytecode.ValNull}, true
+	default:
+		return bytecode.Value{}, false
+	}
+}
+
+func valueLiteral(v bytecode.Value) *ast.LiteralExpr {
+	switch v.Kind {
+	case bytecode.ValInt:
+		return &ast.LiteralExpr{
+			Lexeme: strconv.FormatInt(v.I, 10),
+			Token:  token.TokenNumber,
+			Type:   types.Type{Kind: types.TypeInt},
+		}
+	case bytecode.ValFloat:
+		return &ast.LiteralExpr{
+			Lexeme: strconv.FormatFloat(v.F, 'g', -1, 64),
+			Token:  token.TokenNumber,
+			Type:   types.Type{Kind: types.TypeFloat},
+		}
+	case bytecode.ValBool:
+		tt := token.TokenFalse
+		if v.B {
+			tt = token.TokenTrue
+		}
+		return &ast.LiteralExpr{
+			Lexeme: strconv.FormatBool(v.B),
+			Token:  tt,
+			Type:   types.Type{Kind: types.TypeBool},
+		}
+	case bytecode.ValString:
+		return &ast.LiteralExpr{
+			Lexeme: v.S,
+			Token:  token.TokenText,
+			Type:   types.Type{Kind: types.TypeString},
+		}
+	case bytecode.ValChar:
+		return &ast.LiteralExpr{
+			Lexeme: string([]byte{v.C}),
+			Token:  token.TokenCharText,
+			Type:   types.Type{Kind: types.TypeChar},
+		}
+	default:
+		return &ast.LiteralExpr{
+			Lexeme: "null",
+			Token:  token.TokenNull,
+			Type:   types.Type{Kind: types.TypeNull},
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package optimizer_test
+
+import (
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+	"github.com/ChernykhITMO/compiler/internal/frontend/semantics"
+	"github.com/ChernykhITMO/compiler/internal/optimizer"
+)
+
+// optimize разбирает и проверяет src и прогоняет через оптимизатор.
+func optimize(t *testing.T, src string) (*ast.Program, []optimizer.Warning) {
+	t.Helper()
+	prog := parser.NewParser(lexer.NewLexer(src).Tokenize()).ParseProgram()
+	if errs := semantics.NewChecker().Check(prog); len(errs) > 0 {
+		t.Fatalf("check: [%s] %s", errs[0].Type, errs[0].Message)
+	}
+	return prog, optimizer.NewOptimizer().Optimize(prog)
+}
+
+func function(t *testing.T, prog *ast.Program, name string) *ast.FunctionDecl {
+	t.Helper()
+	for _, fn := range prog.Functions {
+		if fn.Name == name {
+			return fn
+		}
+	}
+	t.Fatalf("no function %s", name)
+	return nil
+}
+
+// returned — выражение единственного return в теле f.
+func returned(t *testing.T, fn *ast.FunctionDecl) ast.Expr {
+	t.Helper()
+	for _, stmt := range fn.Body.Statements {
+		if ret, ok := stmt.(*ast.ReturnStmt); ok {
+			return ret.Value
+		}
+	}
+	t.Fatalf("no return in %s", fn.Name)
+	return nil
+}
+
+func TestFoldConstants(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"arithmetic", "return 2 * 3 + 1", "7"},
+		{"unary", "return -(4 - 10)", "6"},
+		{"comparison", "bool b = 3 < 2\n    return b", "false"},
+		{"propagated local", "int x = 10\n    return x * 2", "20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := "int"
+			if tt.want == "false" {
+				typ = "bool"
+			}
+			prog, _ := optimize(t, "function f() "+typ+" {\n    "+tt.body+"\n}\n")
+			lit, ok := returned(t, function(t, prog, "f")).(*ast.LiteralExpr)
+			if !ok || lit.Lexeme != tt.want {
+				t.Fatalf("return %#v, want literal %s", returned(t, function(t, prog, "f")), tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldKeepsAssignedLocal(t *testing.T) {
+	prog, _ := optimize(t, `
+function f(int n) int {
+    int x = 10
+    if (n > 0) {
+        x = n
+    }
+    return x * 2
+}
+`)
+	if _, ok := returned(t, function(t, prog, "f")).(*ast.BinaryExpr); !ok {
+		t.Fatal("x is reassigned and must not be replaced by its initializer")
+	}
+}
+
+func TestDeadBranchElimination(t *testing.T) {
+	prog, _ := optimize(t, `
+function f() int {
+    while (1 > 2) {
+        return 3
+    }
+    if (1 > 2) {
+        return 1
+    } else {
+        return 2
+    }
+    return 4
+}
+`)
+	body := function(t, prog, "f").Body.Statements
+	if len(body) != 1 {
+		t.Fatalf("got %d statements, want only the else branch", len(body))
+	}
+	block, ok := body[0].(*ast.BlockStmt)
+	if !ok || len(block.Statements) != 1 {
+		t.Fatalf("statement %T, want the else block", body[0])
+	}
+	ret := block.Statements[0].(*ast.ReturnStmt)
+	if lit, ok := ret.Value.(*ast.LiteralExpr); !ok || lit.Lexeme != "2" {
+		t.Fatalf("else block returns %#v, want 2", ret.Value)
+	}
+}
+
+func TestFoldedOverflowWarns(t *testing.T) {
+	prog, warnings := optimize(t, `
+function f() int {
+    return 9223372036854775807 + 1
+}
+`)
+	if len(warnings) != 1 || warnings[0].Type != "Folded overflow" {
+		t.Fatalf("warnings = %v, want one Folded overflow", warnings)
+	}
+	// переполнение не сворачивается: результат определяет VM
+	if _, ok := returned(t, function(t, prog, "f")).(*ast.BinaryExpr); !ok {
+		t.Fatalf("return %#v, want the unfolded sum", returned(t, function(t, prog, "f")))
+	}
+}
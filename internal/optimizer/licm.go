@@ -0,0 +1,601 @@
+package optimizer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ChernykhITMO/compiler/internal/bytecode"
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/token"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+// loop — общие части while и for.
+type loop struct {
+	cond *ast.Expr
+	body *ast.BlockStmt
+	incr *ast.Stmt // только у for
+}
+
+// induction — переменная, которая меняется в цикле ровно одним
+// оператором верхнего уровня вида v = v + c.
+type induction struct {
+	name   string
+	step   int64
+	update ast.Stmt
+}
+
+// loopFunction выносит инвариантные выражения из циклов и заменяет
+// умножения индуктивных переменных сложениями.
+func (o *Optimizer) loopFunction(fn *ast.FunctionDecl) {
+	o.fn = fn
+	o.temps = 0
+
+	o.pushScope()
+	defer o.popScope()
+
+	o.declareParams(fn)
+	o.loopBlock(fn.Body)
+}
+
+func (o *Optimizer) loopBlock(block *ast.BlockStmt) {
+	if block == nil {
+		return
+	}
+	o.pushScope()
+	defer o.popScope()
+
+	out := make([]ast.Stmt, 0, len(block.Statements))
+	for _, stmt := range block.Statements {
+		out = append(out, o.loopStmt(stmt)...)
+	}
+	block.Statements = out
+}
+
+// loopStmt возвращает оператор, перед которым могут стоять объявления
+// вынесенных из цикла временных переменных.
+func (o *Optimizer) loopStmt(stmt ast.Stmt) []ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		o.declare(s)
+
+	case *ast.IfStmt:
+		o.loopBlock(s.ThenBlock)
+		o.loopBlock(s.ElseBlock)
+
+	case *ast.SwitchStmt:
+		for _, b := range s.Blocks() {
+			o.loopBlock(b)
+		}
+
+	case *ast.TryStmt:
+		o.loopBlock(s.Body)
+		for _, c := range s.Catches {
+			o.pushScope()
+			o.declareCatch(c)
+			o.loopBlock(c.Body)
+			o.popScope()
+		}
+
+	case *ast.BlockStmt:
+		o.loopBlock(s)
+
+	case *ast.WhileStmt:
+		pre := o.optimizeLoop(&loop{cond: &s.Condition, body: s.Body})
+		o.loopBlock(s.Body)
+		return append(pre, s)
+
+	case *ast.ForStmt:
+		o.pushScope()
+		defer o.popScope()
+
+		if decl, ok := s.Init.(*ast.VarDeclStmt); ok {
+			o.declare(decl)
+		}
+		pre := o.optimizeLoop(&loop{cond: &s.Condition, body: s.Body, incr: &s.Increment})
+		o.loopBlock(s.Body)
+		if len(pre) == 0 {
+			return []ast.Stmt{s}
+		}
+
+		// init выполняется один раз, поэтому временные переменные идут после него
+		var stmts []ast.Stmt
+		if s.Init != nil {
+			stmts = append(stmts, s.Init)
+			s.Init = nil
+		}
+		stmts = append(stmts, pre...)
+		stmts = append(stmts, s)
+		return []ast.Stmt{&ast.BlockStmt{Statements: stmts}}
+	}
+
+	return []ast.Stmt{stmt}
+}
+
+func (o *Optimizer) optimizeLoop(l *loop) []ast.Stmt {
+	written := make(map[string]int)
+	countWrites(l.body, written)
+	if l.incr != nil && *l.incr != nil {
+		countWritesStmt(*l.incr, written)
+	}
+
+	pre := o.reduceStrength(l, written)
+	pre = append(pre, o.hoistInvariants(l, written)...)
+
+	for _, stmt := range pre {
+		o.declare(stmt.(*ast.VarDeclStmt))
+	}
+	return pre
+}
+
+// hoistInvariants заменяет максимальные инвариантные подвыражения
+// временными переменными. Выносятся только выражения, которые не могут
+// упасть в рантайме: +, -, * и сравнения над int/float, логика над bool.
+// Деление, вызовы и чтение массивов остаются на месте. С проверкой
+// переполнения int-арифметика тоже может упасть и не выносится: цикл мог
+// не выполниться ни разу.
+func (o *Optimizer) hoistInvariants(l *loop, written map[string]int) []ast.Stmt {
+	var pre []ast.Stmt
+	hoisted := make(map[string]string)
+
+	o.mapLoop(l, func(e ast.Expr) ast.Expr {
+		switch e.(type) {
+		case *ast.BinaryExpr, *ast.UnaryExpr:
+		default:
+			return nil
+		}
+		typ, ok := o.invariantType(e, written)
+		if !ok {
+			return nil
+		}
+
+		key := exprKey(e)
+		name, ok := hoisted[key]
+		if !ok {
+			name = o.newTemp()
+			hoisted[key] = name
+			pre = append(pre, &ast.VarDeclStmt{Name: name, Type: typ, Init: e})
+		}
+		return &ast.IdentExpr{Name: name}
+	})
+
+	return pre
+}
+
+func (o *Optimizer) invariantType(e ast.Expr, written map[string]int) (types.Type, bool) {
+	switch ex := e.(type) {
+	case *ast.LiteralExpr:
+		switch ex.Type.Kind {
+		case types.TypeInt, types.TypeFloat, types.TypeBool:
+			return ex.Type, true
+		}
+
+	case *ast.IdentExpr:
+		if written[ex.Name] > 0 {
+			return types.Type{}, false
+		}
+		sym := o.resolve(ex.Name)
+		if sym == nil || sym.captured {
+			return types.Type{}, false
+		}
+		switch sym.typ.Kind {
+		case types.TypeInt, types.TypeFloat, types.TypeBool:
+			return sym.typ, true
+		}
+
+	case *ast.UnaryExpr:
+		t, ok := o.invariantType(ex.Expr, written)
+		if !ok {
+			return types.Type{}, false
+		}
+		switch {
+		case ex.Op == token.TokenMinus && isNumeric(t) && !o.overflows(t):
+			return t, true
+		case ex.Op == token.TokenNot && t.Kind == types.TypeBool:
+			return t, true
+		case ex.Op == token.TokenBitNot && t.Kind == types.TypeInt:
+			return t, true
+		}
+
+	case *ast.CastExpr:
+		// int -> float не падает, остальные преобразования могут
+		t, ok := o.invariantType(ex.Expr, written)
+		if ok && t.Kind == types.TypeInt && ex.Type.Kind == types.TypeFloat {
+			return ex.Type, true
+		}
+
+	case *ast.BinaryExpr:
+		lt, ok := o.invariantType(ex.Left, written)
+		if !ok {
+			return types.Type{}, false
+		}
+		rt, ok := o.invariantType(ex.Right, written)
+		if !ok || lt.Kind != rt.Kind {
+			return types.Type{}, false
+		}
+		boolType := types.Type{Kind: types.TypeBool}
+
+		switch ex.Op {
+		case token.TokenPlus, token.TokenMinus, token.TokenMultiply:
+			if isNumeric(lt) && !o.overflows(lt) {
+				return lt, true
+			}
+		case token.TokenBitAnd, token.TokenBitOr, token.TokenXor:
+			if lt.Kind == types.TypeInt {
+				return lt, true
+			}
+		case token.TokenLess, token.TokenLessEqual, token.TokenGreater, token.TokenGreaterEqual:
+			if isNumeric(lt) {
+				return boolType, true
+			}
+		case token.TokenEqual, token.TokenNotEqual:
+			return boolType, true
+		case token.TokenAnd, token.TokenOr:
+			if lt.Kind == types.TypeBool {
+				return boolType, true
+			}
+		}
+	}
+
+	return types.Type{}, false
+}
+
+func isNumeric(t types.Type) bool {
+	return t.Kind == types.TypeInt || t.Kind == types.TypeFloat
+}
+
+// overflows: арифметика над t может завершиться ошибкой переполнения.
+func (o *Optimizer) overflows(t types.Type) bool {
+	return o.checked && t.Kind == types.TypeInt
+}
+
+// reduceStrength заменяет v * k (k — целый литерал) и v * v, где v —
+// индуктивная переменная, временной переменной t. t обновляется
+// сложением прямо перед обновлением v, так что во всем теле t == v * k.
+// t обновляется и после последней итерации, поэтому с проверкой
+// переполнения замена не делается: лишнее сложение может переполниться.
+func (o *Optimizer) reduceStrength(l *loop, written map[string]int) []ast.Stmt {
+	if o.checked {
+		return nil
+	}
+	var pre []ast.Stmt
+	updates := make(map[ast.Stmt][]ast.Stmt)
+	reduced := make(map[string]string)
+
+	for _, iv := range o.findInductions(l, written) {
+		o.mapLoop(l, func(e ast.Expr) ast.Expr {
+			bin, ok := e.(*ast.BinaryExpr)
+			if !ok || bin.Op != token.TokenMultiply {
+				return nil
+			}
+
+			var key string
+			var update func(temp ast.Expr) ast.Expr
+			switch {
+			case isIdent(bin.Left, iv.name) && isIdent(bin.Right, iv.name):
+				if iv.step != 1 && iv.step != -1 {
+					return nil
+				}
+				// (v ± 1)^2 = v^2 ± v ± v + 1
+				op := token.TokenPlus
+				if iv.step < 0 {
+					op = token.TokenMinus
+				}
+				key = iv.name + "*" + iv.name
+				update = func(temp ast.Expr) ast.Expr {
+					e := &ast.BinaryExpr{Left: temp, Op: op, Right: &ast.IdentExpr{Name: iv.name}}
+					e = &ast.BinaryExpr{Left: e, Op: op, Right: &ast.IdentExpr{Name: iv.name}}
+					return &ast.BinaryExpr{Left: e, Op: token.TokenPlus, Right: intLiteral(1)}
+				}
+			default:
+				k, ok := intFactor(bin, iv.name)
+				if !ok || bytecode.IntOverflows("*", iv.step, k) {
+					return nil
+				}
+				key = fmt.Sprintf("%s*%d", iv.name, k)
+				update = func(temp ast.Expr) ast.Expr {
+					return &ast.BinaryExpr{Left: temp, Op: token.TokenPlus, Right: intLiteral(iv.step * k)}
+				}
+			}
+
+			name, ok := reduced[key]
+			if !ok {
+				name = o.newTemp()
+				reduced[key] = name
+				written[name]++
+				pre = append(pre, &ast.VarDeclStmt{
+					Name: name,
+					Type: types.Type{Kind: types.TypeInt},
+					Init: bin,
+				})
+				// t = t + (...), вычисляется по старому значению v
+				updates[iv.update] = append(updates[iv.update], &ast.AssignStmt{
+					Target: &ast.IdentExpr{Name: name},
+					Value:  update(&ast.IdentExpr{Name: name}),
+				})
+			}
+			return &ast.IdentExpr{Name: name}
+		})
+	}
+
+	if len(updates) == 0 {
+		return pre
+	}
+
+	if l.incr != nil && *l.incr != nil {
+		if upd, ok := updates[*l.incr]; ok {
+			*l.incr = &ast.BlockStmt{Statements: append(upd, *l.incr)}
+		}
+	}
+	out := make([]ast.Stmt, 0, len(l.body.Statements)+len(updates))
+	for _, stmt := range l.body.Statements {
+		out = append(out, updates[stmt]...)
+		out = append(out, stmt)
+	}
+	l.body.Statements = out
+
+	return pre
+}
+
+func (o *Optimizer) findInductions(l *loop, written map[string]int) []*induction {
+	candidates := append([]ast.Stmt{}, l.body.Statements...)
+	if l.incr != nil && *l.incr != nil {
+		candidates = append(candidates, *l.incr)
+	}
+
+	var out []*induction
+	for _, stmt := range candidates {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok {
+			continue
+		}
+		id, ok := assign.Target.(*ast.IdentExpr)
+		if !ok || written[id.Name] != 1 {
+			continue
+		}
+		step, ok := stepOf(id.Name, assign.Value)
+		if !ok {
+			continue
+		}
+		sym := o.resolve(id.Name)
+		if sym == nil || sym.captured || sym.typ.Kind != types.TypeInt || (sym.decl != nil && sym.decl.Init == nil) {
+			continue
+		}
+		out = append(out, &induction{name: id.Name, step: step, update: stmt})
+	}
+	return out
+}
+
+// stepOf распознает v + c, c + v и v - c с целым литералом c.
+func stepOf(name string, e ast.Expr) (int64, bool) {
+	bin, ok := e.(*ast.BinaryExpr)
+	if !ok {
+		return 0, false
+	}
+	switch bin.Op {
+	case token.TokenPlus:
+		if isIdent(bin.Left, name) {
+			return intValue(bin.Right)
+		}
+		if isIdent(bin.Right, name) {
+			return intValue(bin.Left)
+		}
+	case token.TokenMinus:
+		if isIdent(bin.Left, name) {
+			c, ok := intValue(bin.Right)
+			return -c, ok
+		}
+	}
+	return 0, false
+}
+
+func intFactor(bin *ast.BinaryExpr, name string) (int64, bool) {
+	if isIdent(bin.Left, name) {
+		return intValue(bin.Right)
+	}
+	if isIdent(bin.Right, name) {
+		return intValue(bin.Left)
+	}
+	return 0, false
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	id, ok := e.(*ast.IdentExpr)
+	return ok && id.Name == name
+}
+
+func intValue(e ast.Expr) (int64, bool) {
+	lit, ok := e.(*ast.LiteralExpr)
+	if !ok || lit.Type.Kind != types.TypeInt {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(lit.Lexeme, 10, 64)
+	return v, err == nil
+}
+
+func intLiteral(v int64) *ast.LiteralExpr {
+	return &ast.LiteralExpr{
+		Lexeme: strconv.FormatInt(v, 10),
+		Token:  token.TokenNumber,
+		Type:   types.Type{Kind: types.TypeInt},
+	}
+}
+
+func (o *Optimizer) newTemp() string {
+	name := fmt.Sprintf("$t%d", o.temps)
+	o.temps++
+	return name
+}
+
+// exprKey — структурный ключ выражения для поиска одинаковых подвыражений.
+func exprKey(e ast.Expr) string {
+	switch ex := e.(type) {
+	case *ast.LiteralExpr:
+		return ex.Type.String() + ":" + ex.Lexeme
+	case *ast.IdentExpr:
+		return ex.Name
+	case *ast.UnaryExpr:
+		return fmt.Sprintf("(%d %s)", ex.Op, exprKey(ex.Expr))
+	case *ast.BinaryExpr:
+		return fmt.Sprintf("(%s %d %s)", exprKey(ex.Left), ex.Op, exprKey(ex.Right))
+	default:
+		return fmt.Sprintf("%p", e)
+	}
+}
+
+func countWrites(block *ast.BlockStmt, out map[string]int) {
+	if block == nil {
+		return
+	}
+	for _, stmt := range block.Statements {
+		countWritesStmt(stmt, out)
+	}
+}
+
+// countWritesStmt считает присваивания и объявления по имени.
+func countWritesStmt(stmt ast.Stmt, out map[string]int) {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		out[s.Name]++
+	case *ast.AssignStmt:
+		if id, ok := s.Target.(*ast.IdentExpr); ok {
+			out[id.Name]++
+		}
+	case *ast.IfStmt:
+		countWrites(s.ThenBlock, out)
+		countWrites(s.ElseBlock, out)
+	case *ast.WhileStmt:
+		countWrites(s.Body, out)
+	case *ast.SwitchStmt:
+		for _, b := range s.Blocks() {
+			countWrites(b, out)
+		}
+	case *ast.TryStmt:
+		countWrites(s.Body, out)
+		for _, c := range s.Catches {
+			if c.Name != "" {
+				out[c.Name]++
+			}
+			countWrites(c.Body, out)
+		}
+	case *ast.ForStmt:
+		if s.Init != nil {
+			countWritesStmt(s.Init, out)
+		}
+		if s.Increment != nil {
+			countWritesStmt(s.Increment, out)
+		}
+		countWrites(s.Body, out)
+	case *ast.BlockStmt:
+		countWrites(s, out)
+	}
+}
+
+// mapLoop применяет f ко всем выражениям условия, тела и инкремента цикла.
+func (o *Optimizer) mapLoop(l *loop, f func(ast.Expr) ast.Expr) {
+	if *l.cond != nil {
+		*l.cond = mapExpr(*l.cond, f)
+	}
+	mapBlock(l.body, f)
+	if l.incr != nil && *l.incr != nil {
+		mapStmt(*l.incr, f)
+	}
+}
+
+func mapBlock(block *ast.BlockStmt, f func(ast.Expr) ast.Expr) {
+	if block == nil {
+		return
+	}
+	for _, stmt := range block.Statements {
+		mapStmt(stmt, f)
+	}
+}
+
+func mapStmt(stmt ast.Stmt, f func(ast.Expr) ast.Expr) {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		if s.Init != nil {
+			s.Init = mapExpr(s.Init, f)
+		}
+	case *ast.AssignStmt:
+		switch s.Target.(type) {
+		case *ast.IndexExpr, *ast.FieldExpr:
+			s.Target = mapExpr(s.Target, f)
+		}
+		s.Value = mapExpr(s.Value, f)
+	case *ast.ExprStmt:
+		s.Expr = mapExpr(s.Expr, f)
+	case *ast.ReturnStmt:
+		if s.Value != nil {
+			s.Value = mapExpr(s.Value, f)
+		}
+	case *ast.IfStmt:
+		s.Condition = mapExpr(s.Condition, f)
+		mapBlock(s.ThenBlock, f)
+		mapBlock(s.ElseBlock, f)
+	case *ast.WhileStmt:
+		s.Condition = mapExpr(s.Condition, f)
+		mapBlock(s.Body, f)
+	case *ast.SwitchStmt:
+		// значения case — константы, их не трогаем
+		s.Subject = mapExpr(s.Subject, f)
+		for _, b := range s.Blocks() {
+			mapBlock(b, f)
+		}
+	case *ast.TryStmt:
+		mapBlock(s.Body, f)
+		for _, c := range s.Catches {
+			mapBlock(c.Body, f)
+		}
+	case *ast.ThrowStmt:
+		s.Value = mapExpr(s.Value, f)
+	case *ast.ForStmt:
+		if s.Init != nil {
+			mapStmt(s.Init, f)
+		}
+		if s.Condition != nil {
+			s.Condition = mapExpr(s.Condition, f)
+		}
+		if s.Increment != nil {
+			mapStmt(s.Increment, f)
+		}
+		mapBlock(s.Body, f)
+	case *ast.BlockStmt:
+		mapBlock(s, f)
+	}
+}
+
+// mapExpr обходит выражение сверху вниз: если f вернула замену,
+// узел заменяется и внутрь него обход не идет.
+func mapExpr(e ast.Expr, f func(ast.Expr) ast.Expr) ast.Expr {
+	if repl := f(e); repl != nil {
+		return repl
+	}
+	switch ex := e.(type) {
+	case *ast.BinaryExpr:
+		ex.Left = mapExpr(ex.Left, f)
+		ex.Right = mapExpr(ex.Right, f)
+	case *ast.UnaryExpr:
+		ex.Expr = mapExpr(ex.Expr, f)
+	case *ast.CallExpr:
+		for i, arg := range ex.Args {
+			ex.Args[i] = mapExpr(arg, f)
+		}
+	case *ast.IndexExpr:
+		ex.Array = mapExpr(ex.Array, f)
+		ex.Index = mapExpr(ex.Index, f)
+	case *ast.NewArrayExpr:
+		for i, l := range ex.Lengths {
+			ex.Lengths[i] = mapExpr(l, f)
+		}
+	case *ast.ArrayLiteralExpr:
+		for i, el := range ex.Elements {
+			ex.Elements[i] = mapExpr(el, f)
+		}
+	case *ast.FieldExpr:
+		ex.Object = mapExpr(ex.Object, f)
+	case *ast.CastExpr:
+		ex.Expr = mapExpr(ex.Expr, f)
+	}
+	return e
+}
@@ -0,0 +1,168 @@
+package optimizer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/lexer"
+	"github.com/ChernykhITMO/compiler/internal/frontend/parser"
+	"github.com/ChernykhITMO/compiler/internal/frontend/semantics"
+	"github.com/ChernykhITMO/compiler/internal/frontend/token"
+	"github.com/ChernykhITMO/compiler/internal/optimizer"
+)
+
+var opNames = map[token.TokenType]string{
+	token.TokenPlus:     "+",
+	token.TokenMinus:    "-",
+	token.TokenMultiply: "*",
+	token.TokenDivide:   "/",
+}
+
+// render записывает выражение в скобочной форме: (a * b).
+func render(e ast.Expr) string {
+	switch ex := e.(type) {
+	case *ast.IdentExpr:
+		return ex.Name
+	case *ast.LiteralExpr:
+		return ex.Lexeme
+	case *ast.BinaryExpr:
+		op, ok := opNames[ex.Op]
+		if !ok {
+			op = "?"
+		}
+		return "(" + render(ex.Left) + " " + op + " " + render(ex.Right) + ")"
+	}
+	return "<expr>"
+}
+
+// temps собирает временные переменные $tN, которые оптимизатор объявил
+// перед циклами, и присваивания им внутри циклов.
+func temps(block *ast.BlockStmt) (decls, updates map[string]string) {
+	decls = make(map[string]string)
+	updates = make(map[string]string)
+	var walk func(stmt ast.Stmt)
+	walk = func(stmt ast.Stmt) {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			if strings.HasPrefix(s.Name, "$t") {
+				decls[s.Name] = render(s.Init)
+			}
+		case *ast.AssignStmt:
+			if id, ok := s.Target.(*ast.IdentExpr); ok && strings.HasPrefix(id.Name, "$t") {
+				updates[id.Name] = render(s.Value)
+			}
+		case *ast.BlockStmt:
+			for _, st := range s.Statements {
+				walk(st)
+			}
+		case *ast.ForStmt:
+			if s.Increment != nil {
+				walk(s.Increment)
+			}
+			walk(s.Body)
+		case *ast.WhileStmt:
+			walk(s.Body)
+		}
+	}
+	walk(block)
+	return decls, updates
+}
+
+func TestHoistInvariants(t *testing.T) {
+	prog, _ := optimize(t, `
+function f(int a, int b, int n) int {
+    int s = 0
+    int i = 0
+    while (i < n) {
+        s = s + a * b - a / b
+        i = i + 1
+    }
+    return s
+}
+`)
+	decls, _ := temps(function(t, prog, "f").Body)
+	if len(decls) != 1 || decls["$t0"] != "(a * b)" {
+		t.Fatalf("hoisted %v, want only $t0 = (a * b); division may fail and stays", decls)
+	}
+}
+
+func TestHoistSkipsWrittenVariables(t *testing.T) {
+	prog, _ := optimize(t, `
+function f(int a, int b, int n) int {
+    int s = 0
+    for (int i = 0; i < n; i = i + 1) {
+        s = s + a * b
+        a = a + 1
+    }
+    return s
+}
+`)
+	if decls, _ := temps(function(t, prog, "f").Body); len(decls) != 0 {
+		t.Fatalf("hoisted %v, but a changes inside the loop", decls)
+	}
+}
+
+func TestStrengthReduction(t *testing.T) {
+	prog, _ := optimize(t, `
+function f(int n) int {
+    int s = 0
+    for (int i = 0; i < n; i = i + 2) {
+        s = s + i * 3
+    }
+    return s
+}
+`)
+	decls, updates := temps(function(t, prog, "f").Body)
+	if decls["$t0"] != "(i * 3)" || updates["$t0"] != "($t0 + 6)" {
+		t.Fatalf("decls %v, updates %v; want $t0 = (i * 3) advanced by 6", decls, updates)
+	}
+}
+
+// optimizeChecked — optimize для VM с проверкой переполнения.
+func optimizeChecked(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	prog := parser.NewParser(lexer.NewLexer(src).Tokenize()).ParseProgram()
+	if errs := semantics.NewChecker().Check(prog); len(errs) > 0 {
+		t.Fatalf("check: [%s] %s", errs[0].Type, errs[0].Message)
+	}
+	opt := optimizer.NewOptimizer()
+	opt.SetCheckedArithmetic(true)
+	opt.Optimize(prog)
+	return prog
+}
+
+func TestCheckedKeepsIntArithmeticInLoop(t *testing.T) {
+	prog := optimizeChecked(t, `
+function f(int a, int b, float x, float y, int n) float {
+    int s = 0
+    float z = 0.0
+    for (int i = 0; i < n; i = i + 1) {
+        s = s + a * b + i * 3
+        z = z + x * y
+    }
+    return z + float(s)
+}
+`)
+	decls, updates := temps(function(t, prog, "f").Body)
+	// a * b при пустом цикле не вычислялось бы вовсе, а $t = i * 3
+	// прибавлялось бы и после последней итерации
+	if len(decls) != 1 || decls["$t0"] != "(x * y)" || len(updates) != 0 {
+		t.Fatalf("decls %v, updates %v; want only float x * y hoisted", decls, updates)
+	}
+}
+
+func TestStrengthReductionSkipsOverflowingStep(t *testing.T) {
+	prog, _ := optimize(t, `
+function f(int n) int {
+    int s = 0
+    for (int i = 0; i < n; i = i + 2) {
+        s = s + i * 5000000000000000000
+    }
+    return s
+}
+`)
+	if decls, _ := temps(function(t, prog, "f").Body); len(decls) != 0 {
+		t.Fatalf("decls %v; step 2 * 5000000000000000000 overflows", decls)
+	}
+}
@@ -0,0 +1,178 @@
+package optimizer
+
+import (
+	"github.com/ChernykhITMO/compiler/internal/frontend/ast"
+	"github.com/ChernykhITMO/compiler/internal/frontend/types"
+)
+
+const (
+	foldedOverflow = "Folded overflow"
+)
+
+type Warning struct {
+	Type    string
+	Message string
+}
+
+// Optimizer — проход по AST между семантикой и backend.Compiler.
+// Переписывает дерево на месте, поведение программы не меняется.
+type Optimizer struct {
+	warnings []Warning
+
+	fn       *ast.FunctionDecl
+	scopes   []map[string]*symbol
+	assigned map[string]struct{}         // имена, которым что-то присваивается в функции
+	temps    int                         // счетчик временных переменных в функции
+	consts   map[string]*ast.LiteralExpr // глобальные const со свернутым значением
+	checked  bool                        // VM проверяет переполнение int
+}
+
+type symbol struct {
+	typ      types.Type
+	decl     *ast.VarDeclStmt // nil — параметр функции
+	captured bool             // может меняться при вызове вложенной функции
+}
+
+func NewOptimizer() *Optimizer {
+	return &Optimizer{
+		warnings: make([]Warning, 0),
+	}
+}
+
+// SetCheckedArithmetic сообщает, что программа пойдет в VM с проверкой
+// переполнения: тогда int-арифметику нельзя выполнять раньше или чаще,
+// чем в исходной программе.
+func (o *Optimizer) SetCheckedArithmetic(on bool) {
+	o.checked = on
+}
+
+func (o *Optimizer) Optimize(program *ast.Program) []Warning {
+	o.warnings = []Warning{}
+
+	o.foldGlobals(program.Globals)
+	for _, fn := range program.Functions {
+		if fn.Extern != "" {
+			continue
+		}
+		o.foldFunction(fn)
+		o.boundsFunction(fn)
+		o.loopFunction(fn)
+	}
+
+	return o.warnings
+}
+
+func (o *Optimizer) addWarning(warnType, message string) {
+	o.warnings = append(o.warnings, Warning{
+		Type:    warnType,
+		Message: message,
+	})
+}
+
+func (o *Optimizer) pushScope() {
+	o.scopes = append(o.scopes, make(map[string]*symbol))
+}
+
+func (o *Optimizer) popScope() {
+	o.scopes = o.scopes[:len(o.scopes)-1]
+}
+
+func (o *Optimizer) declareParams(fn *ast.FunctionDecl) {
+	for _, p := range fn.Params {
+		o.scopes[len(o.scopes)-1][p.Name] = &symbol{typ: p.Type, captured: p.Captured}
+	}
+}
+
+// declareCatch объявляет переменную ветки catch: она всегда инициализирована,
+// как параметр.
+func (o *Optimizer) declareCatch(c *ast.CatchClause) {
+	if c.Name != "" {
+		o.scopes[len(o.scopes)-1][c.Name] = &symbol{typ: c.Type, captured: c.Captured}
+	}
+}
+
+func (o *Optimizer) declare(decl *ast.VarDeclStmt) {
+	o.scopes[len(o.scopes)-1][decl.Name] = &symbol{typ: decl.Type, decl: decl, captured: decl.Captured}
+}
+
+func (o *Optimizer) resolve(name string) *symbol {
+	for i := len(o.scopes) - 1; i >= 0; i-- {
+		if sym, ok := o.scopes[i][name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+// collectAssigned собирает имена всех переменных, которые хоть раз стоят
+// слева от '='. Без учета областей видимости — консервативно. Тела
+// анонимных функций тоже просматриваются: они пишут в захваченные переменные.
+func collectAssigned(block *ast.BlockStmt, out map[string]struct{}) {
+	if block == nil {
+		return
+	}
+	for _, stmt := range block.Statements {
+		collectAssignedStmt(stmt, out)
+	}
+}
+
+func collectAssignedStmt(stmt ast.Stmt, out map[string]struct{}) {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		collectAssignedExpr(s.Init, out)
+	case *ast.AssignStmt:
+		if id, ok := s.Target.(*ast.IdentExpr); ok {
+			out[id.Name] = struct{}{}
+		}
+		collectAssignedExpr(s.Target, out)
+		collectAssignedExpr(s.Value, out)
+	case *ast.ExprStmt:
+		collectAssignedExpr(s.Expr, out)
+	case *ast.ReturnStmt:
+		collectAssignedExpr(s.Value, out)
+	case *ast.IfStmt:
+		collectAssignedExpr(s.Condition, out)
+		collectAssigned(s.ThenBlock, out)
+		collectAssigned(s.ElseBlock, out)
+	case *ast.WhileStmt:
+		collectAssignedExpr(s.Condition, out)
+		collectAssigned(s.Body, out)
+	case *ast.SwitchStmt:
+		collectAssignedExpr(s.Subject, out)
+		for _, b := range s.Blocks() {
+			collectAssigned(b, out)
+		}
+	case *ast.TryStmt:
+		collectAssigned(s.Body, out)
+		for _, c := range s.Catches {
+			collectAssigned(c.Body, out)
+		}
+	case *ast.ThrowStmt:
+		collectAssignedExpr(s.Value, out)
+	case *ast.ForStmt:
+		if s.Init != nil {
+			collectAssignedStmt(s.Init, out)
+		}
+		collectAssignedExpr(s.Condition, out)
+		if s.Increment != nil {
+			collectAssignedStmt(s.Increment, out)
+		}
+		collectAssigned(s.Body, out)
+	case *ast.BlockStmt:
+		collectAssigned(s, out)
+	}
+}
+
+// collectAssignedExpr собирает присваивания в телах анонимных функций
+// внутри выражения.
+func collectAssignedExpr(e ast.Expr, out map[string]struct{}) {
+	if e == nil {
+		return
+	}
+	mapExpr(e, func(e ast.Expr) ast.Expr {
+		if lit, ok := e.(*ast.FuncLitExpr); ok {
+			collectAssigned(lit.Decl.Body, out)
+		}
+		return nil
+	})
+}